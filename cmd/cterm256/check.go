@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+	"github.com/shagohead/cterm256/pkg/termcolor/contrast"
+)
+
+func checkContrast(scheme termcolor.Table) error {
+	pairs := contrast.CommonPairs()
+	if checkPairs != "" {
+		var err error
+		if pairs, err = parsePairs(checkPairs); err != nil {
+			return err
+		}
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "FG\tBG\tWCAG\tAPCA\t\t")
+	var failed int
+	for _, r := range contrast.Check(scheme, pairs, minWCAG, minAPCA) {
+		status := "pass"
+		switch {
+		case !r.Pass:
+			status = "FAIL"
+			failed++
+		case r.Info:
+			status = "info"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%0.2f\t%0.1f\t%s\t\n", pairIndex(r.Fg), pairIndex(r.Bg), r.WCAG, r.APCA, status)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d pairs below threshold (WCAG %0.2f, APCA %0.1f)", failed, minWCAG, minAPCA)
+	}
+	return nil
+}
+
+func parsePairs(s string) ([]contrast.Pair, error) {
+	var pairs []contrast.Pair
+	for i, raw := range strings.Split(s, ",") {
+		fg, bg, ok := strings.Cut(raw, ":")
+		if !ok {
+			return nil, fmt.Errorf("pairs[%d]: missing «:» separator", i)
+		}
+		var p contrast.Pair
+		var err error
+		if p.Fg, err = parseIndex(fg); err != nil {
+			return nil, fmt.Errorf("pairs[%d]: %v", i, err)
+		}
+		if p.Bg, err = parseIndex(bg); err != nil {
+			return nil, fmt.Errorf("pairs[%d]: %v", i, err)
+		}
+		pairs = append(pairs, p)
+	}
+	return pairs, nil
+}
+
+func parseIndex(s string) (int, error) {
+	switch s {
+	case "b", "bg":
+		return contrast.Background, nil
+	case "f", "fg":
+		return contrast.Foreground, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > 255 {
+		return 0, fmt.Errorf("color number %d out of bounds", n)
+	}
+	return n, nil
+}
+
+func pairIndex(n int) string {
+	switch n {
+	case contrast.Background:
+		return "bg"
+	case contrast.Foreground:
+		return "fg"
+	}
+	return strconv.Itoa(n)
+}
@@ -1,20 +1,31 @@
 package main
 
 import (
+	"bytes"
 	"errors"
 	"flag"
 	"fmt"
 	"io"
 	"os"
-	"path"
 	"strconv"
 	"strings"
 
 	"github.com/shagohead/cterm256/pkg/filetype"
 	_ "github.com/shagohead/cterm256/pkg/filetype/alacritty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/foot"
+	_ "github.com/shagohead/cterm256/pkg/filetype/ghostty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/gnometerminal"
+	_ "github.com/shagohead/cterm256/pkg/filetype/iterm2"
 	_ "github.com/shagohead/cterm256/pkg/filetype/kitty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/konsole"
+	"github.com/shagohead/cterm256/pkg/filetype/osc"
+	_ "github.com/shagohead/cterm256/pkg/filetype/st"
+	_ "github.com/shagohead/cterm256/pkg/filetype/wezterm"
+	_ "github.com/shagohead/cterm256/pkg/filetype/windowsterminal"
+	_ "github.com/shagohead/cterm256/pkg/filetype/xresources"
 	"github.com/shagohead/cterm256/pkg/printer"
 	"github.com/shagohead/cterm256/pkg/termcolor"
+	"github.com/shagohead/cterm256/pkg/termcolor/contrast"
 )
 
 func main() {
@@ -27,60 +38,105 @@ func main() {
 var (
 	fileName     string
 	fileType     = &filetype.Flag{}
+	colorSpace   = &termcolor.SpaceFlag{Name: "hsluv", Space: termcolor.HSLuv}
+	mode         termcolor.Mode
 	debugColors  string
 	printColors  bool
 	printCurrent bool
 	overwrite    bool
 	skipGen      bool
+	keepLayout   bool
+	profileName  string
 	lightOutput  bool
+	minWCAG      float64
+	minAPCA      float64
+	checkPairs   string
+	minContrast  float64
+	apcaMetric   bool
+	simulate     termcolor.Deficiency
+	fallbackName string
+	outputFormat string
+	oscRaw       bool
+	oscWrap      osc.Wrap
+	writeFiles   bool
 )
 
-const cmdMain = "cterm256"
+// Output formats, other than file type of the source.
+const outputOSC = "osc"
+
+const (
+	cmdMain  = "cterm256"
+	cmdCheck = "check"
+)
 
 func run() error {
+	args := os.Args[1:]
+	check := len(args) > 0 && args[0] == cmdCheck
+	if check {
+		args = args[1:]
+	}
 	fs := flag.NewFlagSet(cmdMain, flag.ExitOnError)
-	fs.Var(fileType, "t", "File type. Supported values: "+filetype.RegisteredNames())
+	fs.Var(fileType, "t", "File type. Detected by file name and content if omits. Supported values: "+filetype.RegisteredNames())
 	fs.StringVar(&fileName, "f", "", "Source colorscheme file. If omits STDIN will be used")
 	fs.BoolVar(&overwrite, "w", false, "Overwrite source colorscheme file instead of writing to STDOUT")
+	fs.BoolVar(&writeFiles, "write-includes", false, "Overwrite source file and files, which it includes (kitty) or imports (alacritty), writing each color to the file, which defined it")
+	fs.StringVar(&fallbackName, "fallback", "", "Write OSC 4 escape sequences shell script to `file` for colors, which file type can't store")
+	fs.StringVar(&outputFormat, "o", "", "Output `format` instead of the source file type: one of -t values or osc (escape sequences, which recolor the running terminal)")
+	fs.BoolVar(&oscRaw, "osc-raw", false, "Write raw escape sequences instead of shell script with -o osc")
+	fs.Var(&oscWrap, "osc-wrap", "Wrap escape sequences for terminal multiplexer: none, tmux or screen")
 	fs.BoolVar(&printColors, "print", false, "Print color table instead of colorscheme output")
+	fs.Var(&simulate, "simulate", "Print color table as seen with color vision deficiency: protanopia, deuteranopia, tritanopia or achromatopsia")
 	fs.BoolVar(&printCurrent, "print-current", false, "Print table with current terminal colors")
 	fs.StringVar(&debugColors, "debug", "", "Print HSL data for specified colors (`number/b/bg/f/fg`), separetaed by comma and optionally prefixed with «-» for blank line prepending")
 	fs.BoolVar(&skipGen, "skip-gen", false, "Skip color table generation")
+	fs.BoolVar(&keepLayout, "keep-layout", false, "Keep bright colors of schemes like Solarized, which uses them as grays, instead of moving them to grayscale")
+	fs.StringVar(&profileName, "profile", "", "Generation options profile (TOML or JSON) `file`. Flags take precedence over it")
+	fs.Var(colorSpace, "space", "Color space of generation. Supported values: "+strings.Join(termcolor.SpaceNames(), " "))
 	fs.BoolVar(&lightOutput, "light-stderr", false, "Write light/dark to STDERR")
+	fs.Var(&mode, "mode", "Color scheme mode: auto, dark or light")
+	fs.Float64Var(&minContrast, "min-contrast", 0, "Change lightness of text colors and diff backgrounds to reach minimal contrast (WCAG `ratio` or APCA Lc)")
+	fs.BoolVar(&apcaMetric, "min-contrast-apca", false, "Use APCA Lc instead of WCAG ratio as -min-contrast metric")
+	fs.Float64Var(&minWCAG, "wcag", 4.5, "Check mode: minimal WCAG 2.x contrast `ratio`")
+	fs.Float64Var(&minAPCA, "apca", 0, "Check mode: minimal absolute APCA `Lc`")
+	fs.StringVar(&checkPairs, "pairs", "", "Check mode: color `fg:bg` pairs (number/b/bg/f/fg), separated by comma. Common pairs are checked if omits")
 	fs.Usage = func() {
 		fmt.Fprint(fs.Output(), `Usage: `+cmdMain+` [-h | --help]
+       `+cmdMain+` `+cmdCheck+` [flags]
 
 Patch 8/16 terminal color scheme with generated 239 other ANSI colors.
+In check mode print contrast of generated color pairs and exit non-zero if some are below threshold.
 
 `)
 		fs.PrintDefaults()
 	}
-	if err := fs.Parse(os.Args[1:]); err != nil {
+	if err := fs.Parse(args); err != nil {
 		return err
 	}
+	var encoder filetype.Encoder
+	switch outputFormat {
+	case "", outputOSC:
+	default:
+		ft, ok := filetype.RegisteredTypes()[outputFormat]
+		if !ok {
+			return fmt.Errorf("unknown output format %q: supported values: %s %s", outputFormat, outputOSC, filetype.RegisteredNames())
+		}
+		if encoder, ok = ft.(filetype.Encoder); !ok {
+			return fmt.Errorf("%s file type cannot be used as output format", outputFormat)
+		}
+	}
+	if outputFormat != "" && overwrite {
+		return errors.New("-w cannot be used with -o")
+	}
+	if writeFiles && outputFormat != "" {
+		return errors.New("-write-includes cannot be used with -o")
+	}
+	if writeFiles && fileName == "" {
+		return errors.New("-write-includes requires -f")
+	}
 	if printCurrent {
 		printer.PrintCurrent()
 		return nil
 	}
-	ft := fileType.FileType
-	if ft == nil {
-		if fileName == "" {
-			return errors.New("use -t or -f option")
-		}
-		ext := path.Ext(fileName)
-		for name, ftype := range filetype.RegisteredTypes() {
-			if ftype.Support(fileName, ext) {
-				ft = ftype
-				if !lightOutput {
-					os.Stderr.WriteString("Type determined by file name: " + name + "\n")
-				}
-				break
-			}
-		}
-		if ft == nil {
-			return fmt.Errorf("cannot find supported file type of %s", fileName)
-		}
-	}
 	var in io.Reader
 	var file *os.File
 	in = os.Stdin
@@ -97,19 +153,78 @@ Patch 8/16 terminal color scheme with generated 239 other ANSI colors.
 		defer file.Close()
 		in = file
 	}
-	scheme, err := ft.Parse(in)
+	ft := fileType.FileType
+	if ft == nil {
+		// Input is buffered for detection by content.
+		data, err := io.ReadAll(in)
+		if err != nil {
+			return err
+		}
+		in = bytes.NewReader(data)
+		if ft, err = detectFileType(data); err != nil {
+			return err
+		}
+	}
+	if e, ok := ft.(filetype.Exporter); ok && overwrite {
+		return fmt.Errorf("-w cannot be used with the file type: output is %s, which can't be read back", e.OutputFormat())
+	}
+	var scheme termcolor.Table
+	var err error
+	if p, ok := ft.(filetype.IncludeParser); ok && fileName != "" {
+		scheme, err = p.ParseFile(fileName, in)
+	} else {
+		scheme, err = ft.Parse(in)
+	}
 	if err != nil {
 		return err
 	}
+	if _, ok := scheme.(filetype.MultiFile); writeFiles && !ok {
+		return errors.New("-write-includes is not supported by the file type")
+	}
+	// Source layout is kept if output format is the same.
+	if encoder != nil && any(encoder) == any(ft) {
+		encoder = nil
+	}
+	if encoder != nil {
+		scheme = termcolor.NewPalette(scheme)
+	}
+	opts := termcolor.DefaultOptions()
+	if profileName != "" {
+		if err := termcolor.LoadOptions(profileName, &opts); err != nil {
+			return err
+		}
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "space":
+			opts.Space = colorSpace.Space
+		case "keep-layout":
+			opts.KeepLayout = keepLayout
+		case "mode":
+			opts.Mode = mode
+		case "min-contrast":
+			opts.MinContrast = minContrast
+		}
+	})
+	opts.Contrast = contrast.WCAG
+	if apcaMetric {
+		opts.Contrast = contrast.APCA
+	}
+	if opts.Mode == termcolor.ModeAuto {
+		opts.Mode = termcolor.DetectMode(scheme)
+	}
 	if !skipGen {
-		var warns io.Writer = os.Stderr
+		opts.Warns = os.Stderr
 		if lightOutput {
-			warns = new(noopWriter)
+			opts.Warns = new(noopWriter)
 		}
-		if err := termcolor.Generate(scheme, warns); err != nil {
+		if err := termcolor.GenerateWithOptions(scheme, opts); err != nil {
 			return err
 		}
 	}
+	if check {
+		return checkContrast(scheme)
+	}
 	if debugColors != "" {
 		for i, raw := range strings.Split(debugColors, ",") {
 			if len(raw) > 0 && raw[0] == '-' {
@@ -130,6 +245,10 @@ Patch 8/16 terminal color scheme with generated 239 other ANSI colors.
 			}
 		}
 	}
+	if simulate != termcolor.NoDeficiency {
+		printer.PrintScheme(termcolor.Simulate(scheme, simulate))
+		return nil
+	}
 	if printColors {
 		printer.PrintScheme(scheme)
 		return nil
@@ -137,6 +256,56 @@ Patch 8/16 terminal color scheme with generated 239 other ANSI colors.
 	if debugColors != "" {
 		return nil
 	}
+	if encoder != nil {
+		if scheme, err = encoder.Encode(scheme); err != nil {
+			return err
+		}
+	}
+	if outputFormat == outputOSC {
+		if err := osc.Write(os.Stdout, scheme, osc.Options{Script: !oscRaw, Wrap: oscWrap}); err != nil {
+			return err
+		}
+	} else if writeFiles {
+		if err := writeIncludes(scheme.(filetype.MultiFile)); err != nil {
+			return err
+		}
+		if err := writeFallback(scheme); err != nil {
+			return err
+		}
+	} else if err := write(scheme, file); err != nil {
+		return err
+	}
+	if lightOutput {
+		os.Stderr.WriteString(opts.Mode.String())
+	}
+	return nil
+}
+
+// detectFileType returns file type of the input by the file name and content.
+func detectFileType(data []byte) (filetype.FileType, error) {
+	names := filetype.Detect(fileName, data[:min(len(data), filetype.HeadSize)])
+	switch len(names) {
+	case 0:
+		if fileName == "" {
+			return nil, errors.New("cannot detect file type of STDIN, use -t option")
+		}
+		return nil, fmt.Errorf("cannot find supported file type of %s, use -t option", fileName)
+	case 1:
+	default:
+		return nil, fmt.Errorf("ambiguous file type (%s), use -t option", strings.Join(names, ", "))
+	}
+	if !lightOutput {
+		by := "content"
+		if fileName != "" {
+			by = "file name and content"
+		}
+		os.Stderr.WriteString("Type determined by " + by + ": " + names[0] + "\n")
+	}
+	return filetype.RegisteredTypes()[names[0]], nil
+}
+
+// write writes patched color scheme to STDOUT or source file.
+func write(scheme termcolor.Table, file *os.File) error {
 	var w termcolor.Writer = os.Stdout
 	if overwrite {
 		if _, err := file.Seek(0, io.SeekStart); err != nil {
@@ -147,16 +316,76 @@ Patch 8/16 terminal color scheme with generated 239 other ANSI colors.
 	if err := scheme.Write(w); err != nil {
 		return err
 	}
-	if lightOutput {
-		if scheme.Color(1).Lightness() > scheme.Color(16).Lightness() {
-			os.Stderr.WriteString("dark")
-		} else {
-			os.Stderr.WriteString("light")
+	if overwrite {
+		// Patched file could be shorter than the source one.
+		n, err := file.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return err
+		}
+		if err := file.Truncate(n); err != nil {
+			return err
+		}
+	}
+	return writeFallback(scheme)
+}
+
+// writeIncludes overwrites files, which define colors of the scheme.
+func writeIncludes(scheme filetype.MultiFile) error {
+	files, err := scheme.Files()
+	if err != nil {
+		return err
+	}
+	for name, data := range files {
+		if err := os.WriteFile(name, data, 0o644); err != nil {
+			return err
+		}
+		if !lightOutput {
+			os.Stderr.WriteString("Written: " + name + "\n")
 		}
 	}
 	return nil
 }
 
+// writeFallback writes escape sequences for colors, which aren't stored by limited file types.
+func writeFallback(scheme termcolor.Table) error {
+	limited, ok := scheme.(filetype.Limited)
+	if !ok {
+		return nil
+	}
+	indexes := limited.Unsupported()
+	if len(indexes) == 0 {
+		return nil
+	}
+	if fallbackName == "" {
+		fmt.Fprintf(os.Stderr, "colors %s can't be stored in the file type, use -fallback to write them as escape sequences\n", formatRanges(indexes))
+		return nil
+	}
+	file, err := os.Create(fallbackName)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return osc.WriteColors(file, scheme, indexes, osc.Options{Script: true, Wrap: oscWrap})
+}
+
+// formatRanges returns sorted indexes as comma separated ranges, like «0, 16-255».
+func formatRanges(indexes []int) string {
+	var ranges []string
+	for i := 0; i < len(indexes); {
+		j := i
+		for j+1 < len(indexes) && indexes[j+1] == indexes[j]+1 {
+			j++
+		}
+		r := strconv.Itoa(indexes[i])
+		if j > i {
+			r += "-" + strconv.Itoa(indexes[j])
+		}
+		ranges = append(ranges, r)
+		i = j + 1
+	}
+	return strings.Join(ranges, ", ")
+}
+
 type noopWriter struct{}
 
 // Write implements io.Writer.
@@ -2,10 +2,6 @@ module github.com/shagohead/cterm256
 
 go 1.23
 
-require (
-	github.com/google/go-cmp v0.6.0
-	github.com/hsluv/hsluv-go v2.0.0+incompatible
-	github.com/pelletier/go-toml/v2 v2.2.3
-)
+require github.com/pelletier/go-toml/v2 v2.2.3
 
-require github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+require github.com/lucasb-eyer/go-colorful v1.2.0
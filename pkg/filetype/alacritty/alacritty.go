@@ -1,9 +1,16 @@
 package alacritty
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/pelletier/go-toml/v2"
 
@@ -18,125 +25,334 @@ func init() {
 type fileType struct{}
 
 // Parse implements ftypes.FileType.
+// Imports aren't followed, because there is no directory to resolve them.
 func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
-	cs := new(colorScheme)
-	if err := toml.NewDecoder(input).Decode(&cs.config); err != nil {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]any
+	if err := toml.Unmarshal(src, &config); err != nil {
+		return nil, err
+	}
+	cs := &colorScheme{src: src}
+	if err := cs.parseConfig(config); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// ParseFile implements filetype.IncludeParser.
+// Imports are loaded in order before the importing file, which takes precedence, as in Alacritty.
+func (f *fileType) ParseFile(name string, input io.Reader) (termcolor.Table, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	name = filepath.Clean(name)
+	cs := &colorScheme{src: src, owners: make(map[string]int)}
+	if err := cs.load(name, src, []string{name}); err != nil {
+		return nil, err
+	}
+	config := make(map[string]any)
+	for i, s := range cs.sources {
+		for _, key := range colorKeys() {
+			if lookup(s.config, key) {
+				cs.owners[key] = i
+			}
+		}
+		merge(config, s.config)
+	}
+	if err := cs.parseConfig(config); err != nil {
 		return nil, err
 	}
-	colorsv, ok := cs.config["colors"]
+	cs.parsed = &colors{indexed: cs.indexed, background: cs.background, foreground: cs.foreground}
+	return cs, nil
+}
+
+// load appends the file to sources after files, which it imports.
+func (cs *colorScheme) load(name string, src []byte, chain []string) error {
+	var config map[string]any
+	if err := toml.Unmarshal(src, &config); err != nil {
+		return err
+	}
+	imports, err := importNames(config)
+	if err != nil {
+		return err
+	}
+	for _, imp := range imports {
+		imp = filetype.IncludePath(imp, filepath.Dir(name))
+		if slices.Contains(chain, imp) {
+			return fmt.Errorf("%s: recursive import", imp)
+		}
+		data, err := os.ReadFile(imp)
+		// Missing files are skipped, as Alacritty does.
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := cs.load(imp, data, append(slices.Clip(chain), imp)); err != nil {
+			return fmt.Errorf("%s: %v", imp, err)
+		}
+	}
+	cs.sources = append(cs.sources, &source{name: name, src: src, config: config})
+	return nil
+}
+
+// importNames returns paths of general.import or deprecated top level import.
+func importNames(config map[string]any) ([]string, error) {
+	value, ok := config["import"]
+	if general, isMap := config["general"].(map[string]any); isMap {
+		if v, found := general["import"]; found {
+			value, ok = v, true
+		}
+	}
+	if !ok {
+		return nil, nil
+	}
+	list, ok := value.([]any)
 	if !ok {
-		return nil, errors.New(`missing "colors" key`)
+		return nil, fmt.Errorf("import: unexpected type %T", value)
+	}
+	names := make([]string, 0, len(list))
+	for i, v := range list {
+		name, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("import[%d]: unexpected type %T", i, v)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// merge merges tables of src into dst recursively, other values of src replace the ones of dst.
+func merge(dst, src map[string]any) {
+	for key, v := range src {
+		if table, ok := v.(map[string]any); ok {
+			sub, ok := dst[key].(map[string]any)
+			if !ok {
+				sub = make(map[string]any)
+				dst[key] = sub
+			}
+			merge(sub, table)
+			continue
+		}
+		dst[key] = v
+	}
+}
+
+// colorKeys returns dotted keys of colors, which are written by the file type.
+func colorKeys() []string {
+	keys := []string{"colors.primary.background", "colors.primary.foreground", indexedKey}
+	for _, section := range []string{"normal", "bright"} {
+		for _, name := range baseColors {
+			keys = append(keys, "colors."+section+"."+name)
+		}
+	}
+	return keys
+}
+
+// lookup reports whether config has value of the dotted key.
+func lookup(config map[string]any, key string) bool {
+	var v any = config
+	for _, part := range strings.Split(key, ".") {
+		table, ok := v.(map[string]any)
+		if !ok {
+			return false
+		}
+		if v, ok = table[part]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (cs *colorScheme) parseConfig(config map[string]any) error {
+	colorsv, ok := config["colors"]
+	if !ok {
+		return errors.New(`missing "colors" key`)
 	}
 	colors, ok := colorsv.(map[string]any)
 	if !ok {
-		return nil, fmt.Errorf(`colors: unexpected type %T`, colorsv)
+		return fmt.Errorf(`colors: unexpected type %T`, colorsv)
 	}
 	if err := cs.parseIndexedSection(colors); err != nil {
-		return nil, err
+		return err
 	}
 	if err := cs.parseBaseColors(colors, "normal", 0); err != nil {
-		return nil, err
+		return err
 	}
 	if err := cs.parseBaseColors(colors, "bright", 8); err != nil {
-		return nil, err
+		return err
 	}
 	primaryv, ok := colors["primary"]
 	if !ok {
-		return cs, nil
+		return nil
 	}
 	primary, ok := primaryv.(map[string]any)
 	if !ok {
-		return nil, fmt.Errorf("primary: unexpected type %T", primaryv)
+		return fmt.Errorf("primary: unexpected type %T", primaryv)
 	}
 	if err := cs.parsePrimaryColor(primary, "background", &cs.background); err != nil {
-		return nil, err
+		return err
 	}
 	if err := cs.parsePrimaryColor(primary, "foreground", &cs.foreground); err != nil {
-		return nil, err
+		return err
 	}
-	return cs, nil
+	return nil
+}
+
+var (
+	colorsTable = regexp.MustCompile(`(?m)^\s*\[\[?colors\.(primary|normal|bright|indexed_colors)\]\]?`)
+	colorsKey   = regexp.MustCompile(`(?m)^\s*(indexed_colors|(primary|normal|bright)\.\w+)\s*=`)
+	// Main config, which only imports colors (import key is top level in older versions).
+	importKey = regexp.MustCompile(`(?m)^\s*(general\.)?import\s*=`)
+)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if colorsTable.Match(head) || colorsKey.Match(head) || importKey.Match(head) {
+		return 90
+	}
+	return 0
 }
 
 // Support implements ftypes.FileType.
+// WezTerm color schemes are TOML files too, so the content decides between them.
 func (f *fileType) Support(name string, ext string) bool {
-	if ext == ".toml" {
-		return true
-	}
-	return false
+	return ext == ".toml"
 }
 
 var _ filetype.FileType = (*fileType)(nil)
 
+var _ filetype.Detector = (*fileType)(nil)
+
+var _ filetype.IncludeParser = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := &colorScheme{
+		background: src.Background(),
+		foreground: src.Foreground(),
+	}
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
 type colorScheme struct {
 	indexed    [256]termcolor.Color
 	background termcolor.Color
 	foreground termcolor.Color
-	config     map[string]any
+	src        []byte
+
+	// Main file and files, which it imports, in order of loading, if ParseFile is used.
+	sources []*source
+	// Source index of the effective value by color key.
+	owners map[string]int
+	// Colors as they are parsed, if ParseFile is used.
+	parsed *colors
+}
+
+// Colors of the scheme.
+type colors struct {
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// Config file: the main one or imported.
+type source struct {
+	name   string
+	src    []byte
+	config map[string]any
 }
 
 // Write implements termcolor.Table.
+// Only colors are changed in the source, the rest of it is kept as is.
+// Colors of imported files are written to the source only if they are changed,
+// so the imported files (like themes) still take effect.
 func (cs *colorScheme) Write(w termcolor.Writer) error {
-	var colors map[string]any
-	if v, ok := cs.config["colors"]; ok {
-		colors = v.(map[string]any)
-	} else {
-		colors = make(map[string]any)
-		cs.config["colors"] = colors
+	doc, err := parseDocument(cs.src)
+	if err != nil {
+		return err
 	}
-
-	for _, sec := range []string{"normal", "bright", "primary"} {
-		if _, ok := colors[sec]; !ok {
-			colors[sec] = make(map[string]any)
+	var owns func(key string) bool
+	if cs.parsed != nil {
+		main := len(cs.sources) - 1
+		owns = func(key string) bool {
+			owner, ok := cs.owners[key]
+			return !ok || owner == main || cs.changed(key)
 		}
 	}
-
-	if err := cs.setIndexedColors(colors); err != nil {
+	out, err := doc.patch(cs, owns)
+	if err != nil {
 		return err
 	}
-	cs.setPrimaryColor(colors, "background", cs.background)
-	cs.setPrimaryColor(colors, "foreground", cs.foreground)
-	return toml.NewEncoder(w).Encode(cs.config)
+	_, err = w.Write(out)
+	return err
 }
 
-func (cs *colorScheme) setPrimaryColor(colors map[string]any, key string, c termcolor.Color) {
-	if c.Nil() {
-		return
+// changed reports whether color of the key differs from the parsed one.
+// Indexed colors are changed if any of colors above 15 is changed,
+// since the array of the importing file replaces the imported one.
+func (cs *colorScheme) changed(key string) bool {
+	differs := func(a, b termcolor.Color) bool {
+		return !a.Nil() && (b.Nil() || a.HEX() != b.HEX())
+	}
+	switch key {
+	case "colors.primary.background":
+		return differs(cs.background, cs.parsed.background)
+	case "colors.primary.foreground":
+		return differs(cs.foreground, cs.parsed.foreground)
+	case indexedKey:
+		for n := 16; n < len(cs.indexed); n++ {
+			if differs(cs.indexed[n], cs.parsed.indexed[n]) {
+				return true
+			}
+		}
+		return false
+	}
+	for n, name := range baseColors {
+		switch key {
+		case "colors.normal." + name:
+			return differs(cs.indexed[n], cs.parsed.indexed[n])
+		case "colors.bright." + name:
+			return differs(cs.indexed[n+8], cs.parsed.indexed[n+8])
+		}
 	}
-	colors["primary"].(map[string]any)[key] = c.HEX()
+	return false
 }
 
-func (cs *colorScheme) setIndexedColors(colors map[string]any) error {
-	initial := make(map[int]map[string]any, 256)
-	parseIndexedSection(colors, func(m map[string]any) error {
-		n, err := parseIndexValue(m)
+// Files implements filetype.MultiFile.
+func (cs *colorScheme) Files() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	main := len(cs.sources) - 1
+	for i, src := range cs.sources {
+		doc, err := parseDocument(src.src)
 		if err != nil {
-			return err
+			return nil, fmt.Errorf("%s: %v", src.name, err)
 		}
-		initial[n] = m
-		return nil
-	})
-
-	indexed := make([]any, 0, 256)
-	for n, c := range cs.indexed {
-		if c.Nil() {
-			if orig, ok := initial[n]; ok {
-				indexed = append(indexed, orig)
+		out, err := doc.patch(cs, func(key string) bool {
+			owner, ok := cs.owners[key]
+			if !ok {
+				owner = main
 			}
-			continue
+			return owner == i
+		})
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", src.name, err)
 		}
-		if n < 16 {
-			bnum := n
-			bname := "normal"
-			if n > 7 {
-				bnum -= 8
-				bname = "bright"
-			}
-			colors[bname].(map[string]any)[baseColorName(bnum)] = c.HEX()
+		if !bytes.Equal(out, src.src) {
+			files[src.name] = out
 		}
-		indexed = append(indexed, map[string]any{"index": n, "color": c.HEX()})
 	}
-	colors["indexed_colors"] = indexed
-	return nil
+	return files, nil
 }
 
 func (cs *colorScheme) parsePrimaryColor(src map[string]any, key string, dst *termcolor.Color) error {
@@ -234,13 +450,11 @@ func (cs *colorScheme) parseBaseColors(src map[string]any, section string, offse
 	for name, val := range colors {
 		idx := baseColorIndex(name)
 		if idx < 0 {
-			fmt.Printf("%s.%s: unknown color index\n", section, name)
-			continue
+			return fmt.Errorf("%s.%s: unknown color name", section, name)
 		}
 		col, ok := val.(string)
 		if !ok {
-			fmt.Printf("%s.%s: unexpected type %T\n", section, name, val)
-			continue
+			return fmt.Errorf("%s.%s: unexpected type %T", section, name, val)
 		}
 		cs.indexed[idx+offset] = termcolor.FromHEX(col)
 	}
@@ -256,13 +470,6 @@ func baseColorIndex(name string) int {
 	return -1
 }
 
-func baseColorName(index int) string {
-	if index < 8 {
-		return baseColors[index]
-	}
-	return ""
-}
-
 var baseColors = []string{
 	"black",
 	"red",
@@ -298,3 +505,5 @@ func (cs *colorScheme) Foreground() termcolor.Color {
 }
 
 var _ termcolor.Table = (*colorScheme)(nil)
+
+var _ filetype.MultiFile = (*colorScheme)(nil)
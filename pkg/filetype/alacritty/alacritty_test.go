@@ -143,6 +143,17 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParseInvalidBaseColor(t *testing.T) {
+	for _, src := range []string{
+		"[colors.normal]\norange = '#ff8800'\n",
+		"[colors.bright]\nred = 1\n",
+	} {
+		if _, err := new(fileType).Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("Parse(%q) succeeded", src)
+		}
+	}
+}
+
 func TestParseExampleFile(t *testing.T) {
 	in, err := os.Open("testdata/alacritty.toml")
 	if err != nil {
@@ -155,3 +166,312 @@ func TestParseExampleFile(t *testing.T) {
 		t.Fatal("Parse() fails:", err)
 	}
 }
+
+func TestWrite(t *testing.T) {
+	write := func(t *testing.T, src string, set func(cs termcolor.Table)) string {
+		t.Helper()
+		cs, err := new(fileType).Parse(strings.NewReader(src))
+		if err != nil {
+			t.Fatal("Parse():", err)
+		}
+		set(cs)
+		out := new(strings.Builder)
+		if err := cs.Write(out); err != nil {
+			t.Fatal("Write():", err)
+		}
+		return out.String()
+	}
+
+	for _, tt := range []struct {
+		name string
+		src  string
+		set  func(cs termcolor.Table)
+		want string
+	}{
+		{
+			name: "unchanged",
+			src: `# Theme
+[window]
+opacity = 0.9 # comment
+
+[colors.primary]
+background = "#303446"  # bg
+foreground = "#C6D0F5"
+`,
+			set: func(termcolor.Table) {},
+			want: `# Theme
+[window]
+opacity = 0.9 # comment
+
+[colors.primary]
+background = "#303446"  # bg
+foreground = "#C6D0F5"
+`,
+		},
+		{
+			name: "missing keys and tables",
+			src: `[colors.primary]
+background = '#303446' # bg
+
+[colors.normal]
+black = '#51576D'
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(0, termcolor.FromHEX("#000000"))
+				cs.SetColor(1, termcolor.FromHEX("#ff0000"))
+				cs.SetColor(9, termcolor.FromHEX("#ff5555"))
+				cs.SetColor(16, termcolor.FromHEX("#000001"))
+			},
+			want: `[colors.primary]
+background = '#303446' # bg
+
+[colors.normal]
+black = '#000000'
+red = '#ff0000'
+
+[colors.bright]
+red = '#ff5555'
+
+[[colors.indexed_colors]]
+index = 16
+color = '#000001'
+`,
+		},
+		{
+			name: "dotted keys",
+			src: `[colors]
+primary.background = "#303446"
+normal = { black = "#51576D" }
+`,
+			set: func(cs termcolor.Table) {
+				cs.(*colorScheme).foreground = termcolor.FromHEX("#ffffff")
+			},
+			want: `[colors]
+primary.background = "#303446"
+primary.foreground = "#ffffff"
+normal = { black = "#51576D" }
+`,
+		},
+		{
+			name: "empty table",
+			src: `[colors.primary] # comment
+
+[colors.selection]
+text = "#080808"
+`,
+			set: func(cs termcolor.Table) {
+				cs.(*colorScheme).background = termcolor.FromHEX("#000000")
+			},
+			want: `[colors.primary] # comment
+background = "#000000"
+
+[colors.selection]
+text = "#080808"
+`,
+		},
+		{
+			name: "inline indexed colors",
+			src: `[colors]
+indexed_colors = [
+  { index = 16, color = "#000000" }, # first
+  { index = 17, color = "#111111" },
+]
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(17, termcolor.FromHEX("#222222"))
+				cs.SetColor(18, termcolor.FromHEX("#333333"))
+			},
+			want: `[colors]
+indexed_colors = [
+  { index = 16, color = "#000000" }, # first
+  { index = 17, color = "#222222" },
+  { index = 18, color = "#333333" },
+]
+`,
+		},
+		{
+			name: "comment after the last element",
+			src: `[colors]
+indexed_colors = [
+  { index = 16, color = '#000000' }, # first
+]
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(17, termcolor.FromHEX("#111111"))
+			},
+			want: `[colors]
+indexed_colors = [
+  { index = 16, color = '#000000' }, # first
+  { index = 17, color = '#111111' },
+]
+`,
+		},
+		{
+			name: "comment after the last element without comma",
+			src:  "[colors]\r\nindexed_colors = [\r\n  { index = 16, color = '#000000' } # first\r\n]\r\n",
+			set: func(cs termcolor.Table) {
+				cs.SetColor(17, termcolor.FromHEX("#111111"))
+			},
+			want: "[colors]\r\nindexed_colors = [\r\n  { index = 16, color = '#000000' }, # first\r\n  { index = 17, color = '#111111' },\r\n]\r\n",
+		},
+		{
+			name: "comment after opening",
+			src: `[colors]
+indexed_colors = [ # none yet
+]
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(16, termcolor.FromHEX("#000000"))
+			},
+			want: `[colors]
+indexed_colors = [ # none yet
+  { index = 16, color = '#000000' },
+]
+`,
+		},
+		{
+			name: "empty indexed colors",
+			src: `[colors]
+indexed_colors = []
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(16, termcolor.FromHEX("#000000"))
+			},
+			want: `[colors]
+indexed_colors = [
+  { index = 16, color = '#000000' },
+]
+`,
+		},
+		{
+			name: "indexed colors tables",
+			src: `[[colors.indexed_colors]]
+color = '#51576D'
+index = 16
+
+[font]
+size = 15
+`,
+			set: func(cs termcolor.Table) {
+				cs.SetColor(16, termcolor.FromHEX("#000000"))
+				cs.SetColor(17, termcolor.FromHEX("#111111"))
+			},
+			want: `[[colors.indexed_colors]]
+color = '#000000'
+index = 16
+
+[font]
+size = 15
+
+[[colors.indexed_colors]]
+index = 17
+color = '#111111'
+`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := write(t, tt.src, tt.set); got != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFileImports(t *testing.T) {
+	const name = "testdata/import/alacritty.toml"
+	in, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	table, err := new(fileType).ParseFile(name, in)
+	if err != nil {
+		t.Fatal("ParseFile():", err)
+	}
+	cs := table.(*colorScheme)
+	for _, tt := range []struct {
+		name  string
+		color termcolor.Color
+		want  string
+	}{
+		{name: "foreground", color: cs.Foreground(), want: "#ffffff"},
+		{name: "background", color: cs.Background(), want: "#1d1f21"},
+		{name: "black", color: cs.Color(0), want: "#282a2e"},
+		{name: "red", color: cs.Color(1), want: "#a54242"},
+	} {
+		if got := tt.color.HEX(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+
+	cs.foreground = termcolor.FromHEX("#eeeeee")
+	cs.SetColor(0, termcolor.FromHEX("#000000"))
+	cs.SetColor(9, termcolor.FromHEX("#ff5555"))
+	cs.SetColor(16, termcolor.FromHEX("#111111"))
+	files, err := cs.Files()
+	if err != nil {
+		t.Fatal("Files():", err)
+	}
+	for name, want := range map[string]string{
+		"testdata/import/alacritty.toml": `[general]
+import = ["themes/theme.toml", "missing.toml"]
+
+[font]
+size = 12
+
+[colors.primary]
+foreground = "#eeeeee"
+
+[colors.bright]
+red = "#ff5555"
+
+[[colors.indexed_colors]]
+index = 16
+color = "#111111"
+`,
+		"testdata/import/themes/theme.toml": `# Theme
+[colors.primary]
+background = "#1d1f21"
+foreground = "#c5c8c6"
+
+[colors.normal]
+black = "#000000"
+red = "#a54242"
+`,
+	} {
+		if got := string(files[name]); got != want {
+			t.Errorf("Files()[%s] =\n%s\nwant\n%s", name, got, want)
+		}
+	}
+	if len(files) != 2 {
+		t.Errorf("Files() returns %d files, want 2", len(files))
+	}
+
+	// Unchanged colors of imported files must not be pinned in the main file.
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	want := `[general]
+import = ["themes/theme.toml", "missing.toml"]
+
+[font]
+size = 12
+
+[colors.primary]
+foreground = "#eeeeee"
+
+[colors.normal]
+black = "#000000"
+
+[colors.bright]
+red = "#ff5555"
+
+[[colors.indexed_colors]]
+index = 16
+color = "#111111"
+`
+	if got := out.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
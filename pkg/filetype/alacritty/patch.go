@@ -0,0 +1,226 @@
+package alacritty
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/shagohead/cterm256/pkg/filetype/internal/tomlpatch"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// Element of colors.indexed_colors array.
+type indexedColor struct {
+	index int
+	color unstable.Range
+	ok    bool
+}
+
+// document is the layout of the source TOML with indexed colors.
+type document struct {
+	*tomlpatch.Document
+
+	indexed      map[int]unstable.Range
+	arrayTables  bool // indexed_colors are [[colors.indexed_colors]] tables.
+	inlineArray  bool // indexed_colors is an inline array.
+	lastElement  int  // Offset after the last element of inline array.
+	arrayOpening int  // Offset after «[» of inline array.
+}
+
+const indexedKey = "colors.indexed_colors"
+
+func parseDocument(src []byte) (*document, error) {
+	doc := &document{
+		Document:    tomlpatch.New(src, "colors", '\''),
+		indexed:     make(map[int]unstable.Range),
+		lastElement: -1,
+	}
+	var elem *indexedColor
+	flush := func() {
+		if elem != nil && elem.ok {
+			doc.indexed[elem.index] = elem.color
+		}
+		elem = nil
+	}
+	err := doc.Parse(func(header []string, expr *unstable.Node) error {
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			flush()
+			if expr.Kind == unstable.ArrayTable && strings.Join(header, ".") == indexedKey {
+				doc.arrayTables = true
+				elem = &indexedColor{}
+			}
+		case unstable.KeyValue:
+			key := tomlpatch.Keys(expr.Key())
+			value := expr.Value()
+			if elem != nil {
+				elem.set(doc, key, value)
+			}
+			path := strings.Join(append(slices.Clone(header), key...), ".")
+			if value.Kind == unstable.Array && path == indexedKey {
+				return doc.parseInlineArray(expr, value)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	flush()
+	return doc, nil
+}
+
+func (e *indexedColor) set(doc *document, key []string, value *unstable.Node) {
+	if len(key) != 1 {
+		return
+	}
+	switch {
+	case key[0] == "index" && value.Kind == unstable.Integer:
+		if n, err := strconv.Atoi(string(value.Data)); err == nil {
+			e.index = n
+		}
+	case key[0] == "color" && value.Kind == unstable.String:
+		e.color, e.ok = value.Raw, true
+		doc.Quote = doc.Src[value.Raw.Offset]
+	}
+}
+
+func (doc *document) parseInlineArray(expr, array *unstable.Node) error {
+	doc.inlineArray = true
+	// Array node has no raw range, so it is found after the key.
+	end := tomlpatch.KeysEnd(expr.Key())
+	open := bytes.IndexByte(doc.Src[end:], '[')
+	if open < 0 {
+		return fmt.Errorf("%s: array start not found", indexedKey)
+	}
+	doc.arrayOpening = end + open + 1
+	elems := array.Children()
+	for elems.Next() {
+		table := elems.Node()
+		if table.Kind != unstable.InlineTable {
+			continue
+		}
+		var elem indexedColor
+		kvs := table.Children()
+		for kvs.Next() {
+			kv := kvs.Node()
+			elem.set(doc, tomlpatch.Keys(kv.Key()), kv.Value())
+		}
+		if elem.ok {
+			doc.indexed[elem.index] = elem.color
+		}
+		end := closingBrace(doc.Src, int(table.Raw.Offset))
+		if end < 0 {
+			return fmt.Errorf("%s: unterminated inline table", indexedKey)
+		}
+		doc.lastElement = end
+	}
+	return nil
+}
+
+// closingBrace returns offset after «}» of the inline table, started at offset.
+func closingBrace(src []byte, offset int) int {
+	var quote byte
+	for i := offset + 1; i < len(src); i++ {
+		switch c := src[i]; {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '}':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// patch returns source with colors of the scheme.
+// If owns isn't nil, only colors of the keys, for which it returns true, are written.
+func (doc *document) patch(cs *colorScheme, owns func(key string) bool) ([]byte, error) {
+	p := doc.Patch()
+	set := func(table, key string, c termcolor.Color) {
+		if owns == nil || owns(table+"."+key) {
+			p.Set(table, key, c)
+		}
+	}
+	set("colors.primary", "background", cs.background)
+	set("colors.primary", "foreground", cs.foreground)
+	for n, name := range baseColors {
+		set("colors.normal", name, cs.indexed[n])
+	}
+	for n, name := range baseColors {
+		set("colors.bright", name, cs.indexed[n+8])
+	}
+
+	var indexes []int
+	for n, c := range cs.indexed {
+		if c.Nil() || owns != nil && !owns(indexedKey) {
+			continue
+		}
+		if r, ok := doc.indexed[n]; ok {
+			p.Edit(doc.Replace(r, c)...)
+		} else if n >= 16 {
+			indexes = append(indexes, n)
+		}
+	}
+	if len(indexes) > 0 {
+		if doc.inlineArray {
+			p.Edit(doc.insertElements(cs, indexes)...)
+		} else {
+			if doc.Defined(indexedKey) && !doc.arrayTables {
+				return nil, fmt.Errorf("%s: unsupported definition", indexedKey)
+			}
+			for _, n := range indexes {
+				p.Append(fmt.Sprintf("\n[[%s]]\nindex = %d\ncolor = %s\n", indexedKey, n, doc.Quoted(cs.indexed[n].HEX())))
+			}
+		}
+	}
+	return p.Bytes()
+}
+
+// insertElements returns edits, which add elements into inline indexed_colors array.
+// Elements are inserted after the comment of the line, which ends the last element.
+func (doc *document) insertElements(cs *colorScheme, indexes []int) []tomlpatch.Edit {
+	var edits []tomlpatch.Edit
+	text := &strings.Builder{}
+	pos := doc.arrayOpening
+	indent := "  "
+	if doc.lastElement >= 0 {
+		pos = doc.lastElement
+		rest := doc.Src[pos:]
+		if trimmed := bytes.TrimLeft(rest, " \t"); len(trimmed) > 0 && trimmed[0] == ',' {
+			pos += len(rest) - len(trimmed) + 1
+		} else {
+			edits = append(edits, tomlpatch.Edit{Start: pos, End: pos, Text: ","})
+		}
+		lineStart := bytes.LastIndexByte(doc.Src[:doc.lastElement], '\n') + 1
+		line := doc.Src[lineStart:]
+		indent = string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+	}
+	pos, atLineEnd := doc.restOfLine(pos)
+	for _, n := range indexes {
+		fmt.Fprintf(text, "\n%s{ index = %d, color = %s },", indent, n, doc.Quoted(cs.indexed[n].HEX()))
+	}
+	if !atLineEnd {
+		text.WriteByte('\n')
+	}
+	return append(edits, tomlpatch.Edit{Start: pos, End: pos, Text: text.String()})
+}
+
+// restOfLine returns end of line, if there is only whitespace or comment after offset.
+// Otherwise offset is returned as is.
+func (doc *document) restOfLine(offset int) (int, bool) {
+	rest := bytes.TrimLeft(doc.Src[offset:], " \t")
+	if len(rest) == 0 || rest[0] == '#' || rest[0] == '\n' || rest[0] == '\r' {
+		return doc.LineEnd(offset), true
+	}
+	return offset, false
+}
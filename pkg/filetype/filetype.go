@@ -4,6 +4,10 @@ import (
 	"errors"
 	"flag"
 	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
 	"strings"
 
 	"github.com/shagohead/cterm256/pkg/termcolor"
@@ -63,3 +67,86 @@ func (f *Flag) String() string {
 }
 
 var _ flag.Value = (*Flag)(nil)
+
+// Limited is implemented by tables of file types, which can't store all 256 colors.
+type Limited interface {
+	// Unsupported returns indexes of colors, which can't be written.
+	Unsupported() []int
+}
+
+// Encoder is implemented by file types, which can write colors of any table.
+type Encoder interface {
+	// Encode returns table of the file type with colors of [cs].
+	Encode(cs termcolor.Table) (termcolor.Table, error)
+}
+
+// Exporter is implemented by file types, which write another format than they parse,
+// so the source file can't be overwritten with the output.
+type Exporter interface {
+	// OutputFormat returns name of the written format.
+	OutputFormat() string
+}
+
+// Detector is implemented by file types, which can recognize their content.
+type Detector interface {
+	// Detect returns confidence (0-100) of that head of the input has the file type.
+	Detect(head []byte) int
+}
+
+// IncludeParser is implemented by file types, which configs can include other files.
+type IncludeParser interface {
+	// ParseFile parses the named file and files, which it includes, with precedence of the terminal.
+	ParseFile(name string, input io.Reader) (termcolor.Table, error)
+}
+
+// MultiFile is implemented by tables, which colors are defined in several files.
+type MultiFile interface {
+	// Files returns changed content by file name, where each color is written to the file,
+	// which defined it. Colors, which aren't defined in any file, are written to the main one.
+	Files() (map[string][]byte, error)
+}
+
+// IncludePath returns path of the included file: «~/» prefix is expanded to the home directory
+// and relative path is resolved against dir.
+func IncludePath(name, dir string) string {
+	if rest, ok := strings.CutPrefix(name, "~/"); ok {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, rest)
+		}
+	}
+	if filepath.IsAbs(name) {
+		return filepath.Clean(name)
+	}
+	return filepath.Join(dir, name)
+}
+
+// HeadSize is the size of input head, which is enough for detection.
+const HeadSize = 64 * 1024
+
+// nameConfidence is added to the confidence of file types, which support the file name.
+const nameConfidence = 25
+
+// Detect scores registered file types by file name (if not empty) and the head of content.
+// It returns sorted names of the best scored types: more than one if detection is ambiguous.
+func Detect(name string, head []byte) []string {
+	var best []string
+	var max int
+	for ftName, ftype := range ftypes {
+		var score int
+		if d, ok := ftype.(Detector); ok {
+			score = d.Detect(head)
+		}
+		if name != "" && ftype.Support(name, path.Ext(name)) {
+			score += nameConfidence
+		}
+		switch {
+		case score == 0 || score < max:
+		case score > max:
+			max, best = score, []string{ftName}
+		default:
+			best = append(best, ftName)
+		}
+	}
+	slices.Sort(best)
+	return best
+}
@@ -0,0 +1,105 @@
+package filetype_test
+
+import (
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	_ "github.com/shagohead/cterm256/pkg/filetype/alacritty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/foot"
+	_ "github.com/shagohead/cterm256/pkg/filetype/ghostty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/gnometerminal"
+	_ "github.com/shagohead/cterm256/pkg/filetype/iterm2"
+	_ "github.com/shagohead/cterm256/pkg/filetype/kitty"
+	_ "github.com/shagohead/cterm256/pkg/filetype/konsole"
+	_ "github.com/shagohead/cterm256/pkg/filetype/st"
+	_ "github.com/shagohead/cterm256/pkg/filetype/wezterm"
+	_ "github.com/shagohead/cterm256/pkg/filetype/windowsterminal"
+	_ "github.com/shagohead/cterm256/pkg/filetype/xresources"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestEncode(t *testing.T) {
+	src := new(termcolor.Palette)
+	src.SetBackground(termcolor.FromHEX("#1d1f21"))
+	src.SetForeground(termcolor.FromHEX("#c5c8c6"))
+	for n, hex := range []string{
+		"#282a2e", "#a54242", "#8c9440", "#de935f",
+		"#5f819d", "#85678f", "#5e8d87", "#707880",
+	} {
+		src.SetColor(n, termcolor.FromHEX(hex))
+	}
+	if err := termcolor.Generate(src, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	for name, ft := range filetype.RegisteredTypes() {
+		t.Run(name, func(t *testing.T) {
+			enc, ok := ft.(filetype.Encoder)
+			if !ok {
+				t.Skip("not an Encoder")
+			}
+			cs, err := enc.Encode(src)
+			if err != nil {
+				t.Fatal("Encode():", err)
+			}
+			out := &strings.Builder{}
+			if err := cs.Write(out); err != nil {
+				t.Fatal("Write():", err)
+			}
+			// Output of exporters can't be parsed back.
+			if _, ok := ft.(filetype.Exporter); ok {
+				return
+			}
+			if got := filetype.Detect("", []byte(out.String())); !slices.Equal(got, []string{name}) {
+				t.Errorf("Detect() of encoded = %v", got)
+			}
+			got, err := ft.Parse(strings.NewReader(out.String()))
+			if err != nil {
+				t.Fatalf("Parse() of encoded fails: %v\n%s", err, out)
+			}
+			colors := 256
+			if _, ok := cs.(filetype.Limited); ok {
+				colors = 16
+			}
+			for n := range colors {
+				if got, want := got.Color(n).HEX(), src.Color(n).HEX(); got != want {
+					t.Errorf("color %d = %s, want %s", n, got, want)
+				}
+			}
+			if got, want := got.Background().HEX(), src.Background().HEX(); got != want {
+				t.Errorf("background = %s, want %s", got, want)
+			}
+			if got, want := got.Foreground().HEX(), src.Foreground().HEX(); got != want {
+				t.Errorf("foreground = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestDetectUnknown(t *testing.T) {
+	if got := filetype.Detect("", []byte("# comment only\n")); len(got) != 0 {
+		t.Errorf("Detect() of unknown content = %v, want none", got)
+	}
+	// Weak evidence of the content (foot) is outweighed by file name,
+	// but TOML files without palette keys are ambiguous.
+	if got := filetype.Detect("colors.toml", []byte("[colors]\n")); !slices.Equal(got, []string{"alacritty", "wezterm"}) {
+		t.Errorf("Detect() by file name = %v, want [alacritty wezterm]", got)
+	}
+	if got := filetype.Detect("colors.toml", []byte("[colors]\nindexed_colors = []\n")); !slices.Equal(got, []string{"alacritty"}) {
+		t.Errorf("Detect() by content = %v, want [alacritty]", got)
+	}
+	if got := filetype.Detect("colors.toml", []byte("[colors]\nansi = []\n")); !slices.Equal(got, []string{"wezterm"}) {
+		t.Errorf("Detect() by content = %v, want [wezterm]", got)
+	}
+	for _, src := range []string{
+		"[general]\nimport = [\"themes/theme.toml\"]\n",
+		"general.import = [\"themes/theme.toml\"]\n",
+		"import = [\"themes/theme.toml\"]\n",
+	} {
+		if got := filetype.Detect("alacritty.toml", []byte(src)); !slices.Equal(got, []string{"alacritty"}) {
+			t.Errorf("Detect() of import only config %q = %v, want [alacritty]", src, got)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package foot
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/filetype/internal/ini"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("foot", &fileType{})
+}
+
+type fileType struct{}
+
+// Parse implements ftypes.FileType.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	file, err := ini.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	cs := &colorScheme{file: file, section: "colors"}
+	// Since foot 1.22 [colors] is an alias of [colors-dark].
+	if file.HasSection("colors-dark") {
+		cs.section = "colors-dark"
+	}
+	for _, key := range file.Keys(cs.section) {
+		n := colorIndex(key)
+		var dst *termcolor.Color
+		switch {
+		case n >= 0:
+			dst = &cs.indexed[n]
+		case key == "background":
+			dst = &cs.background
+		case key == "foreground":
+			dst = &cs.foreground
+		default:
+			continue
+		}
+		val, _ := file.Get(cs.section, key)
+		if !termcolor.HEX.MatchString(val) {
+			return nil, fmt.Errorf("%s.%s: unsupported color value %q", cs.section, key, val)
+		}
+		*dst = termcolor.FromHEX(val)
+	}
+	return cs, nil
+}
+
+// colorIndex returns palette index of the key or -1.
+func colorIndex(key string) int {
+	for prefix, offset := range map[string]int{"regular": 0, "bright": 8} {
+		if s, ok := strings.CutPrefix(key, prefix); ok {
+			if n, err := strconv.Atoi(s); err == nil && n >= 0 && n < 8 {
+				return n + offset
+			}
+			return -1
+		}
+	}
+	if n, err := strconv.Atoi(key); err == nil && n >= 16 && n < 256 {
+		return n
+	}
+	return -1
+}
+
+// colorKey returns key of the palette index.
+func colorKey(n int) string {
+	switch {
+	case n < 8:
+		return "regular" + strconv.Itoa(n)
+	case n < 16:
+		return "bright" + strconv.Itoa(n-8)
+	}
+	return strconv.Itoa(n)
+}
+
+var (
+	sectionLine = regexp.MustCompile(`(?m)^\s*\[colors(-dark)?\]`)
+	colorLine   = regexp.MustCompile(`(?m)^\s*(regular|bright)[0-7]\s*=`)
+)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if !sectionLine.Match(head) {
+		return 0
+	}
+	if colorLine.Match(head) {
+		return 90
+	}
+	// Same section is used by TOML configs.
+	return 20
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	return ext == ".ini" && strings.Contains(name, "foot")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	table, err := f.Parse(strings.NewReader(""))
+	if err != nil {
+		return nil, err
+	}
+	cs := table.(*colorScheme)
+	cs.background, cs.foreground = src.Background(), src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+type colorScheme struct {
+	file       *ini.File
+	section    string
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// hex returns color value in foot format: without «#».
+func hex(c termcolor.Color) string {
+	return strings.TrimPrefix(c.HEX(), "#")
+}
+
+// Write implements termcolor.Table.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	if !cs.foreground.Nil() {
+		cs.file.Set(cs.section, "foreground", hex(cs.foreground))
+	}
+	if !cs.background.Nil() {
+		cs.file.Set(cs.section, "background", hex(cs.background))
+	}
+	for n, c := range cs.indexed {
+		if !c.Nil() {
+			cs.file.Set(cs.section, colorKey(n), hex(c))
+		}
+	}
+	_, err := cs.file.WriteTo(w)
+	return err
+}
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
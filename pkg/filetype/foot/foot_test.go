@@ -0,0 +1,127 @@
+package foot
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+const config = `# foot config
+[main]
+font=monospace:size=11
+
+[colors]
+# dark palette
+foreground=c5c8c6
+background=1d1f21
+regular0=282a2e
+regular1=a54242
+bright0=373b41
+16=de935f
+alpha=0.9
+
+[mouse]
+hide-when-typing=yes
+`
+
+func parse(t *testing.T, src string) *colorScheme {
+	t.Helper()
+	table, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	return table.(*colorScheme)
+}
+
+func write(t *testing.T, cs termcolor.Table) string {
+	t.Helper()
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	return out.String()
+}
+
+func TestParse(t *testing.T) {
+	cs := parse(t, config)
+	if cs.section != "colors" {
+		t.Errorf("section = %s, want colors", cs.section)
+	}
+	for _, tt := range []struct {
+		name  string
+		color termcolor.Color
+		want  string
+	}{
+		{name: "foreground", color: cs.Foreground(), want: "#c5c8c6"},
+		{name: "background", color: cs.Background(), want: "#1d1f21"},
+		{name: "regular0", color: cs.Color(0), want: "#282a2e"},
+		{name: "regular1", color: cs.Color(1), want: "#a54242"},
+		{name: "bright0", color: cs.Color(8), want: "#373b41"},
+		{name: "16", color: cs.Color(16), want: "#de935f"},
+	} {
+		if got := tt.color.HEX(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if _, err := new(fileType).Parse(strings.NewReader("[colors]\nregular0=black\n")); err == nil {
+		t.Error("Parse() of color name succeeded")
+	}
+}
+
+func TestParseColorsDark(t *testing.T) {
+	cs := parse(t, `[colors]
+regular0=ffffff
+
+[colors-dark]
+regular0=000000
+`)
+	if cs.section != "colors-dark" {
+		t.Errorf("section = %s, want colors-dark", cs.section)
+	}
+	if got := cs.Color(0).HEX(); got != "#000000" {
+		t.Errorf("Color(0) = %s, want #000000", got)
+	}
+	cs.SetColor(0, termcolor.FromHEX("#111111"))
+	want := `[colors]
+regular0=ffffff
+
+[colors-dark]
+regular0=111111
+`
+	if got := write(t, cs); !strings.HasPrefix(got, want) {
+		t.Errorf("Write() =\n%s\nwant prefix\n%s", got, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cs := parse(t, config)
+	if got := write(t, cs); got != config {
+		t.Errorf("Write() without changes =\n%s\nwant\n%s", got, config)
+	}
+
+	cs.SetColor(1, termcolor.FromHEX("#ff0000"))
+	cs.SetColor(9, termcolor.FromHEX("#ff5555"))
+	cs.SetColor(17, termcolor.FromHEX("#123456"))
+	cs.foreground = termcolor.FromHEX("#ffffff")
+	got := write(t, cs)
+	for _, s := range []string{
+		"# foot config\n[main]\nfont=monospace:size=11\n\n[colors]\n# dark palette\nforeground=ffffff\n",
+		"\nregular1=ff0000\n",
+		"\nbright1=ff5555\n",
+		"\n17=123456\n",
+		"\nalpha=0.9\n",
+		"\n[mouse]\nhide-when-typing=yes\n",
+	} {
+		if !strings.Contains(got, s) {
+			t.Errorf("Write() output has no %q:\n%s", s, got)
+		}
+	}
+	// Only comments have «#».
+	if n := strings.Count(got, "#"); n != 2 {
+		t.Errorf("Write() output has colors with «#»:\n%s", got)
+	}
+	if i, j := strings.Index(got, "17=123456"), strings.Index(got, "[mouse]"); i > j {
+		t.Errorf("new color is written out of [colors] section:\n%s", got)
+	}
+}
@@ -0,0 +1,224 @@
+package ghostty
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("ghostty", &fileType{})
+}
+
+type fileType struct{}
+
+// Parse implements ftypes.FileType.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	cs := &colorScheme{named: make(map[string]termcolor.Color)}
+	scan := bufio.NewScanner(input)
+	var ln int
+	for scan.Scan() {
+		ln++
+		l, err := parseLine(scan.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%d line: %v", ln, err)
+		}
+		switch {
+		case l.key == "":
+		case l.index >= 0:
+			cs.indexed[l.index] = l.color
+		default:
+			cs.named[l.key] = l.color
+		}
+		cs.lines = append(cs.lines, l)
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// Names of colors, in order of writing.
+var namedColors = []string{
+	"background",
+	"foreground",
+	"cursor-color",
+	"cursor-text",
+	"selection-background",
+	"selection-foreground",
+}
+
+// Line of the config. Color lines are split to be updated in place.
+type line struct {
+	raw string
+
+	// Color lines: raw is prefix + value + suffix.
+	key    string
+	index  int // Palette color number or -1 for named colors.
+	color  termcolor.Color
+	prefix string
+	value  string
+	suffix string
+}
+
+func (l line) String() string {
+	if l.key == "" {
+		return l.raw
+	}
+	return l.prefix + l.value + l.suffix
+}
+
+// parseLine parses color line. Other lines (comments, other keys, colors
+// with values like X11 color names) are kept as raw ones.
+func parseLine(raw string) (line, error) {
+	l := line{raw: raw, index: -1}
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" || trimmed[0] == '#' {
+		return l, nil
+	}
+	key, val, ok := strings.Cut(trimmed, "=")
+	if !ok {
+		return l, nil
+	}
+	key, val = strings.TrimSpace(key), strings.TrimSpace(val)
+	index := -1
+	if key == "palette" {
+		num, col, ok := strings.Cut(val, "=")
+		if !ok {
+			return l, fmt.Errorf("palette: missing color number in %q", val)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(num))
+		if err != nil || n < 0 || n > 255 {
+			return l, fmt.Errorf("palette: invalid color number %q", num)
+		}
+		index, val = n, strings.TrimSpace(col)
+	} else if !slices.Contains(namedColors, key) {
+		return l, nil
+	}
+	if !termcolor.HEX.MatchString(val) {
+		return l, nil
+	}
+	l.key, l.index, l.value = key, index, val
+	l.color = termcolor.FromHEX(val)
+	l.suffix = raw[len(strings.TrimRight(raw, " \t")):]
+	l.prefix = raw[:len(raw)-len(l.suffix)-len(val)]
+	return l, nil
+}
+
+var (
+	paletteLine = regexp.MustCompile(`(?m)^\s*palette\s*=\s*\d{1,3}\s*=`)
+	colorLine   = regexp.MustCompile(`(?m)^\s*(background|foreground)\s*=\s*#?[0-9a-fA-F]{6}\s*$`)
+)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	switch {
+	case paletteLine.Match(head):
+		return 90
+	case colorLine.Match(head):
+		return 50
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+// Ghostty config and themes are files without extension, usually placed in ghostty directory.
+func (f *fileType) Support(name string, ext string) bool {
+	return strings.Contains(name, "ghostty")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := &colorScheme{named: make(map[string]termcolor.Color)}
+	cs.named["background"] = src.Background()
+	cs.named["foreground"] = src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+type colorScheme struct {
+	lines   []line
+	named   map[string]termcolor.Color
+	indexed [256]termcolor.Color
+}
+
+// Write implements termcolor.Table.
+// Color lines are updated in place, missing colors are appended to the end.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	definedNamed := make(map[string]bool)
+	var definedIndexed [256]bool
+	s := &strings.Builder{}
+	for _, l := range cs.lines {
+		switch {
+		case l.key == "":
+		case l.index >= 0:
+			definedIndexed[l.index] = true
+			l.value = cs.update(l, cs.indexed[l.index])
+		default:
+			definedNamed[l.key] = true
+			l.value = cs.update(l, cs.named[l.key])
+		}
+		s.WriteString(l.String())
+		s.WriteByte('\n')
+	}
+	for _, name := range namedColors {
+		if c := cs.named[name]; !c.Nil() && !definedNamed[name] {
+			fmt.Fprintf(s, "%s = %s\n", name, c.HEX())
+		}
+	}
+	for i, c := range cs.indexed {
+		if !c.Nil() && !definedIndexed[i] {
+			fmt.Fprintf(s, "palette = %d=%s\n", i, c.HEX())
+		}
+	}
+	_, err := w.WriteString(s.String())
+	return err
+}
+
+// update returns value of the color line: the source one, if color isn't changed.
+func (cs *colorScheme) update(l line, c termcolor.Color) string {
+	if c.Nil() || c.HEX() == l.color.HEX() {
+		return l.value
+	}
+	return c.HEX()
+}
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.named["background"]
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.named["foreground"]
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
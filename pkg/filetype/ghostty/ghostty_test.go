@@ -0,0 +1,89 @@
+package ghostty
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+const theme = `# Theme
+font-size = 13
+
+palette = 0=#1d1f21
+palette =  1 = #CC6666  
+background = 1d1f21
+foreground = #c5c8c6
+cursor-color = #aeafad
+selection-background = white
+`
+
+func TestParse(t *testing.T) {
+	table, err := new(fileType).Parse(strings.NewReader(theme))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	cs := table.(*colorScheme)
+	for _, tt := range []struct {
+		name  string
+		color termcolor.Color
+		want  string
+	}{
+		{name: "palette 0", color: cs.Color(0), want: "#1d1f21"},
+		{name: "palette 1", color: cs.Color(1), want: "#cc6666"},
+		{name: "background", color: cs.Background(), want: "#1d1f21"},
+		{name: "foreground", color: cs.Foreground(), want: "#c5c8c6"},
+		{name: "cursor-color", color: cs.named["cursor-color"], want: "#aeafad"},
+	} {
+		if got := tt.color.HEX(); got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	if c := cs.named["selection-background"]; !c.Nil() {
+		t.Errorf("selection-background = %s, want X11 name kept raw", c.HEX())
+	}
+
+	for _, src := range []string{"palette = 1", "palette = 256=#000000"} {
+		if _, err := new(fileType).Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("Parse(%q) succeeded", src)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	write := func(cs termcolor.Table) string {
+		t.Helper()
+		out := &strings.Builder{}
+		if err := cs.Write(out); err != nil {
+			t.Fatal("Write():", err)
+		}
+		return out.String()
+	}
+	cs, err := new(fileType).Parse(strings.NewReader(theme))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	if got := write(cs); got != theme {
+		t.Errorf("Write() without changes =\n%s\nwant\n%s", got, theme)
+	}
+
+	cs.SetColor(1, termcolor.FromHEX("#ff0000"))
+	cs.SetColor(2, termcolor.FromHEX("#00ff00"))
+	cs.(*colorScheme).named["foreground"] = termcolor.FromHEX("#ffffff")
+	cs.(*colorScheme).named["cursor-text"] = termcolor.FromHEX("#000000")
+	want := `# Theme
+font-size = 13
+
+palette = 0=#1d1f21
+palette =  1 = #ff0000  
+background = 1d1f21
+foreground = #ffffff
+cursor-color = #aeafad
+selection-background = white
+cursor-text = #000000
+palette = 2=#00ff00
+`
+	if got := write(cs); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
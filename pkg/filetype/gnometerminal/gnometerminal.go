@@ -0,0 +1,287 @@
+package gnometerminal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/filetype/internal/ini"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("gnometerminal", &fileType{})
+}
+
+type fileType struct{}
+
+const (
+	keyPalette    = "palette"
+	keyBackground = "background-color"
+	keyForeground = "foreground-color"
+)
+
+// Parse implements ftypes.FileType.
+// Input is dump of /org/gnome/terminal/legacy/profiles:/ dconf path.
+// Palette of the default profile (or the first one with palette) is used.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	file, err := ini.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	cs := &colorScheme{file: file, section: profileSection(file)}
+	if cs.section == "" {
+		return nil, errors.New("profile with palette not found")
+	}
+	val, _ := file.Get(cs.section, keyPalette)
+	values, err := parseList(val)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s: %v", cs.section, keyPalette, err)
+	}
+	if len(values) > 16 {
+		return nil, fmt.Errorf("%s.%s: %d colors, want at most 16", cs.section, keyPalette, len(values))
+	}
+	for n, s := range values {
+		c, rgb, err := parseColor(s)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", cs.section, keyPalette, err)
+		}
+		cs.indexed[n] = c
+		cs.rgb[n] = rgb
+	}
+	for n := len(values); n > 0 && n < 16; n++ {
+		cs.rgb[n] = cs.rgb[0]
+	}
+	for key, dst := range map[string]*termcolor.Color{keyBackground: &cs.background, keyForeground: &cs.foreground} {
+		val, ok := file.Get(cs.section, key)
+		if !ok {
+			continue
+		}
+		s, err := unquote(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", cs.section, key, err)
+		}
+		if *dst, _, err = parseColor(s); err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", cs.section, key, err)
+		}
+	}
+	return cs, nil
+}
+
+// profileSection returns section of the default profile if it has palette,
+// otherwise the first profile section with palette.
+func profileSection(file *ini.File) string {
+	if val, ok := file.Get("/", "default"); ok {
+		if uuid, err := unquote(val); err == nil {
+			if _, ok := file.Get(":"+uuid, keyPalette); ok {
+				return ":" + uuid
+			}
+		}
+	}
+	for _, section := range file.Sections() {
+		if _, ok := file.Get(section, keyPalette); ok && strings.HasPrefix(section, ":") {
+			return section
+		}
+	}
+	return ""
+}
+
+// unquote returns content of GVariant string in single quotes.
+func unquote(s string) (string, error) {
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return "", fmt.Errorf("unsupported string %s", s)
+	}
+	return s[1 : len(s)-1], nil
+}
+
+// parseList parses GVariant array of strings.
+func parseList(s string) ([]string, error) {
+	inner, ok := strings.CutPrefix(s, "[")
+	if inner, ok = strings.CutSuffix(inner, "]"); !ok {
+		return nil, fmt.Errorf("unsupported list %s", s)
+	}
+	var items []string
+	for _, item := range strings.Split(inner, "',") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.HasSuffix(item, "'") {
+			item += "'"
+		}
+		v, err := unquote(item)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, nil
+}
+
+// parseColor parses «rgb(r,g,b)» or HEX value.
+func parseColor(s string) (c termcolor.Color, rgb bool, err error) {
+	if termcolor.HEX.MatchString(s) {
+		return termcolor.FromHEX(s), false, nil
+	}
+	inner, ok := strings.CutPrefix(s, "rgb(")
+	if inner, ok = strings.CutSuffix(inner, ")"); !ok {
+		return c, false, fmt.Errorf("unsupported color value %q", s)
+	}
+	parts := strings.Split(inner, ",")
+	if len(parts) != 3 {
+		return c, false, fmt.Errorf("unsupported color value %q", s)
+	}
+	var v [3]uint8
+	for i, p := range parts {
+		n, err := strconv.ParseUint(strings.TrimSpace(p), 10, 8)
+		if err != nil {
+			return c, false, fmt.Errorf("unsupported color value %q", s)
+		}
+		v[i] = uint8(n)
+	}
+	return termcolor.FromHEX(fmt.Sprintf("#%02x%02x%02x", v[0], v[1], v[2])), true, nil
+}
+
+// formatColor returns color value in «rgb(r,g,b)» or HEX form.
+func formatColor(c termcolor.Color, rgb bool) string {
+	if !rgb {
+		return c.HEX()
+	}
+	r, g, b := c.RGB()
+	return fmt.Sprintf("rgb(%d,%d,%d)", r, g, b)
+}
+
+var paletteLine = regexp.MustCompile(`(?m)^\s*palette\s*=\s*\[`)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if paletteLine.Match(head) {
+		return 90
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	name = strings.ToLower(name)
+	return ext == ".dconf" || strings.Contains(name, "gnome-terminal") || strings.Contains(name, "gnometerminal")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	table, err := f.Parse(strings.NewReader(template))
+	if err != nil {
+		return nil, err
+	}
+	cs := table.(*colorScheme)
+	cs.background, cs.foreground = src.Background(), src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// template of the encoded profiles dump.
+const template = `[/]
+default='` + profileUUID + `'
+list=['` + profileUUID + `']
+
+[:` + profileUUID + `]
+palette=[]
+use-theme-colors=false
+visible-name='cterm256'
+`
+
+// profileUUID is the identifier of encoded profile.
+const profileUUID = "2a7ad1f8-3b3c-4f06-8c7e-5f6b5a3a8c25"
+
+type colorScheme struct {
+	file       *ini.File
+	section    string
+	rgb        [16]bool // Whether palette entry was in «rgb(r,g,b)» form.
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// Write implements termcolor.Table.
+// Output could be loaded by «dconf load /org/gnome/terminal/legacy/profiles:/».
+// Colors 16-255 can't be stored in profile, see Unsupported.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	palette := make([]string, 0, 16)
+	for n, c := range cs.indexed[:16] {
+		if c.Nil() {
+			break
+		}
+		palette = append(palette, "'"+formatColor(c, cs.rgb[n])+"'")
+	}
+	cs.file.Set(cs.section, keyPalette, "["+strings.Join(palette, ", ")+"]")
+	// Missing keys are appended in this order.
+	for _, kv := range []struct {
+		key string
+		c   termcolor.Color
+	}{
+		{key: keyBackground, c: cs.background},
+		{key: keyForeground, c: cs.foreground},
+	} {
+		if kv.c.Nil() {
+			continue
+		}
+		// Keep form of the source value.
+		rgb := cs.rgb[0]
+		if val, ok := cs.file.Get(cs.section, kv.key); ok {
+			rgb = strings.Contains(val, "rgb(")
+		}
+		cs.file.Set(cs.section, kv.key, "'"+formatColor(kv.c, rgb)+"'")
+	}
+	_, err := cs.file.WriteTo(w)
+	return err
+}
+
+// Unsupported implements filetype.Limited.
+func (cs *colorScheme) Unsupported() []int {
+	var indexes []int
+	for n := 16; n < 256; n++ {
+		if !cs.indexed[n].Nil() {
+			indexes = append(indexes, n)
+		}
+	}
+	return indexes
+}
+
+var _ filetype.Limited = (*colorScheme)(nil)
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
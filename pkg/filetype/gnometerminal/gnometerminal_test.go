@@ -0,0 +1,85 @@
+package gnometerminal
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	in, err := os.Open("testdata/profiles.dconf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		in.Close()
+	})
+	cs, err := new(fileType).Parse(in)
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#1d1f21"},
+		{name: "foreground", color: cs.Foreground(), hex: "#c5c8c6"},
+		{name: "color 1", color: cs.Color(1), hex: "#a54242"},
+		{name: "color 7", color: cs.Color(7), hex: "#707880"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	for _, s := range []string{
+		"palette=['#171421', '#c01c28', '#26a269'",
+		"background-color='rgb(29,31,33)'",
+		"foreground-color='#c5c8c6'",
+		"visible-name='Tomorrow Night'",
+		"palette=['rgb(40,42,46)', 'rgb(165,66,66)'",
+	} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("written file has no %q", s)
+		}
+	}
+	got, err := new(fileType).Parse(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal("written file is invalid:", err)
+	}
+	for n := range 16 {
+		if got.Color(n).HEX() != cs.Color(n).HEX() {
+			t.Errorf("written color %d = %s, want %s", n, got.Color(n).HEX(), cs.Color(n).HEX())
+		}
+	}
+}
+
+func TestWriteMissingKeys(t *testing.T) {
+	const src = "[:b1dcc9dd]\npalette=['#000000', '#ff0000']\nvisible-name='Test'\n"
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	cs.(*colorScheme).background = termcolor.FromHEX("#1d1f21")
+	cs.(*colorScheme).foreground = termcolor.FromHEX("#c5c8c6")
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	want := "[:b1dcc9dd]\npalette=['#000000', '#ff0000']\nvisible-name='Test'\n" +
+		"background-color='#1d1f21'\nforeground-color='#c5c8c6'\n"
+	if got := out.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
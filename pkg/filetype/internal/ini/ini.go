@@ -0,0 +1,160 @@
+// Package ini reads and patches INI files.
+// Layout, comments and unrelated sections are kept untouched.
+package ini
+
+import (
+	"bufio"
+	"io"
+	"slices"
+	"strings"
+)
+
+// File is the line-level representation of INI file.
+type File struct {
+	lines []line
+}
+
+type line struct {
+	raw     string // Original text; used when line is not modified.
+	section string // Section name which line belongs to.
+	header  bool   // Section header line.
+
+	// Key/value lines: raw is prefix + value + suffix.
+	key    string
+	prefix string
+	value  string
+	suffix string
+}
+
+func (l line) String() string {
+	if l.key == "" {
+		return l.raw
+	}
+	return l.prefix + l.value + l.suffix
+}
+
+// Parse reads INI file.
+func Parse(input io.Reader) (*File, error) {
+	f := new(File)
+	scan := bufio.NewScanner(input)
+	scan.Buffer(nil, 1024*1024)
+	var section string
+	for scan.Scan() {
+		l := line{raw: scan.Text()}
+		trimmed := strings.TrimSpace(l.raw)
+		switch {
+		case trimmed == "" || trimmed[0] == '#' || trimmed[0] == ';':
+		case trimmed[0] == '[' && strings.HasSuffix(trimmed, "]"):
+			section = trimmed[1 : len(trimmed)-1]
+			l.header = true
+		default:
+			if eq := strings.IndexByte(l.raw, '='); eq > 0 {
+				l.key = strings.TrimSpace(l.raw[:eq])
+				value := l.raw[eq+1:]
+				start := len(value) - len(strings.TrimLeft(value, " \t"))
+				l.prefix = l.raw[:eq+1+start]
+				value = value[start:]
+				if c := strings.Index(value, " #"); c >= 0 {
+					l.suffix = value[c:]
+					value = value[:c]
+				}
+				trimmed := strings.TrimRight(value, " \t")
+				l.suffix = value[len(trimmed):] + l.suffix
+				l.value = trimmed
+			}
+		}
+		l.section = section
+		f.lines = append(f.lines, l)
+	}
+	return f, scan.Err()
+}
+
+// HasSection reports whether file has section with the name.
+func (f *File) HasSection(section string) bool {
+	for _, l := range f.lines {
+		if l.header && l.section == section {
+			return true
+		}
+	}
+	return false
+}
+
+// Sections returns names of the sections in order of appearance.
+func (f *File) Sections() []string {
+	var names []string
+	for _, l := range f.lines {
+		if l.header && !slices.Contains(names, l.section) {
+			names = append(names, l.section)
+		}
+	}
+	return names
+}
+
+// Keys returns keys of the section in order of appearance.
+func (f *File) Keys(section string) []string {
+	var keys []string
+	for _, l := range f.lines {
+		if l.section == section && l.key != "" {
+			keys = append(keys, l.key)
+		}
+	}
+	return keys
+}
+
+// Get returns value of the key in section. Last one wins if key is repeated.
+func (f *File) Get(section, key string) (string, bool) {
+	var val string
+	var ok bool
+	for _, l := range f.lines {
+		if l.section == section && l.key == key {
+			val, ok = l.value, true
+		}
+	}
+	return val, ok
+}
+
+// Set updates value of the key in section (the last occurrence, as returned by Get).
+// Missing key is appended after the last key of the section
+// and missing section is appended at the end of file.
+func (f *File) Set(section, key, value string) {
+	last, found := -1, -1
+	for i, l := range f.lines {
+		if l.section != section {
+			continue
+		}
+		if l.key == key {
+			found = i
+		}
+		if l.key != "" || l.header {
+			last = i
+		}
+	}
+	if found >= 0 {
+		f.lines[found].value = value
+		return
+	}
+	l := line{section: section, key: key, prefix: key + "=", value: value}
+	if last < 0 {
+		if n := len(f.lines); n > 0 && strings.TrimSpace(f.lines[n-1].raw) != "" {
+			f.lines = append(f.lines, line{section: f.lines[n-1].section})
+		}
+		f.lines = append(f.lines, line{raw: "[" + section + "]", section: section, header: true}, l)
+		return
+	}
+	// Follow key/value separator style of the section.
+	if prev := f.lines[last]; prev.key != "" {
+		l.prefix = key + strings.TrimPrefix(prev.prefix, prev.key)
+	}
+	f.lines = slices.Insert(f.lines, last+1, l)
+}
+
+// WriteTo implements io.WriterTo.
+func (f *File) WriteTo(w io.Writer) (int64, error) {
+	s := &strings.Builder{}
+	for _, l := range f.lines {
+		s.WriteString(l.String())
+		s.WriteByte('\n')
+	}
+	n, err := io.WriteString(w, s.String())
+	return int64(n), err
+}
@@ -0,0 +1,77 @@
+package ini
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	const src = `# comment
+font=monospace:size=11
+
+[colors]
+# palette
+foreground = dcdccc
+background=111111 # inline comment
+regular0 = 222222
+
+[key-bindings]
+scrollback-up-page=Shift+Page_Up
+`
+	f, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	if v, ok := f.Get("colors", "background"); !ok || v != "111111" {
+		t.Errorf("Get(colors, background) = %q, %v", v, ok)
+	}
+	if v, ok := f.Get("", "font"); !ok || v != "monospace:size=11" {
+		t.Errorf("Get(, font) = %q, %v", v, ok)
+	}
+	f.Set("colors", "background", "000000")
+	f.Set("colors", "regular1", "cc9393")
+	f.Set("cursor", "color", "111111 dcdccc")
+
+	out := &strings.Builder{}
+	if _, err := f.WriteTo(out); err != nil {
+		t.Fatal("WriteTo():", err)
+	}
+	const want = `# comment
+font=monospace:size=11
+
+[colors]
+# palette
+foreground = dcdccc
+background=000000 # inline comment
+regular0 = 222222
+regular1 = cc9393
+
+[key-bindings]
+scrollback-up-page=Shift+Page_Up
+
+[cursor]
+color=111111 dcdccc
+`
+	if got := out.String(); got != want {
+		t.Errorf("WriteTo() = %s, want %s", got, want)
+	}
+}
+
+func TestSetDuplicateKey(t *testing.T) {
+	const src = "[colors]\nbackground=111111\nforeground=dcdccc\nbackground=222222\n"
+	f, err := Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	f.Set("colors", "background", "000000")
+	if v, ok := f.Get("colors", "background"); !ok || v != "000000" {
+		t.Errorf("Get(colors, background) = %q, %v, want 000000", v, ok)
+	}
+	out := &strings.Builder{}
+	if _, err := f.WriteTo(out); err != nil {
+		t.Fatal("WriteTo():", err)
+	}
+	if want := "[colors]\nbackground=111111\nforeground=dcdccc\nbackground=000000\n"; out.String() != want {
+		t.Errorf("WriteTo() = %s, want %s", out, want)
+	}
+}
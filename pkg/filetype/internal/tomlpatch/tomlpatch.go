@@ -0,0 +1,292 @@
+// Package tomlpatch patches color values of TOML files.
+// Layout, comments and unrelated keys are kept untouched.
+package tomlpatch
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// Edit of the source: [Start, End) range is replaced with Text.
+// Line breaks of Text are converted to line ending of the source.
+type Edit struct {
+	Start, End int
+	Text       string
+}
+
+// Key/value line, after which missing keys of the table are inserted.
+type anchor struct {
+	end    int    // Offset of the value end.
+	prefix string // Dotted key of the table relative to the header in effect.
+}
+
+// Document is the layout of the source TOML, which is needed for patching.
+type Document struct {
+	Src   []byte
+	Quote byte // Quote of string values in the colors table.
+
+	colors string // Key of the colors table.
+	eol    string // Line ending of the source.
+
+	// String values by full dotted key.
+	values map[string]unstable.Range
+	// Anchors of the tables by full dotted key.
+	anchors map[string]anchor
+	// Tables, which are defined in any way.
+	defined map[string]bool
+}
+
+// Visitor is called for every expression with the header of the table in effect.
+// It records layout, which is specific to the file type.
+type Visitor func(header []string, expr *unstable.Node) error
+
+// New returns document of the source. Quote of string values of the colors table
+// (quote by default) is used for added values.
+func New(src []byte, colors string, quote byte) *Document {
+	doc := &Document{
+		Src:     src,
+		Quote:   quote,
+		colors:  colors,
+		eol:     "\n",
+		values:  make(map[string]unstable.Range),
+		anchors: make(map[string]anchor),
+		defined: make(map[string]bool),
+	}
+	if bytes.Contains(src, []byte("\r\n")) {
+		doc.eol = "\r\n"
+	}
+	return doc
+}
+
+// Parse records layout of the source. Visit may be nil.
+func (doc *Document) Parse(visit Visitor) error {
+	p := &unstable.Parser{}
+	p.Reset(doc.Src)
+	var header []string
+	for p.NextExpression() {
+		expr := p.Expression()
+		switch expr.Kind {
+		case unstable.Table, unstable.ArrayTable:
+			header = Keys(expr.Key())
+			doc.define(header)
+			if expr.Kind == unstable.Table {
+				doc.anchors[strings.Join(header, ".")] = anchor{end: KeysEnd(expr.Key())}
+			}
+		case unstable.KeyValue:
+			key := Keys(expr.Key())
+			full := append(slices.Clone(header), key...)
+			doc.define(full[:len(full)-1])
+			path := strings.Join(full, ".")
+			switch value := expr.Value(); value.Kind {
+			case unstable.String:
+				doc.value(path, value.Raw)
+				doc.anchors[strings.Join(full[:len(full)-1], ".")] = anchor{
+					end:    int(value.Raw.Offset + value.Raw.Length),
+					prefix: strings.Join(key[:len(key)-1], "."),
+				}
+			case unstable.InlineTable:
+				doc.inlineTable(path, value)
+			}
+			doc.defined[path] = true
+		}
+		if visit != nil {
+			if err := visit(header, expr); err != nil {
+				return err
+			}
+		}
+	}
+	return p.Error()
+}
+
+// value records range of the string value.
+func (doc *Document) value(path string, raw unstable.Range) {
+	doc.values[path] = raw
+	if strings.HasPrefix(path, doc.colors+".") {
+		doc.Quote = doc.Src[raw.Offset]
+	}
+}
+
+// inlineTable records string values of the inline table.
+// Inline tables can't be extended, so they don't have anchors.
+func (doc *Document) inlineTable(path string, table *unstable.Node) {
+	doc.defined[path] = true
+	kvs := table.Children()
+	for kvs.Next() {
+		kv := kvs.Node()
+		key := strings.Join(Keys(kv.Key()), ".")
+		switch value := kv.Value(); value.Kind {
+		case unstable.String:
+			doc.value(path+"."+key, value.Raw)
+		case unstable.InlineTable:
+			doc.inlineTable(path+"."+key, value)
+		}
+	}
+}
+
+// define marks tables of the path as defined.
+func (doc *Document) define(path []string) {
+	for i := range path {
+		doc.defined[strings.Join(path[:i+1], ".")] = true
+	}
+}
+
+// Defined reports whether the table or key of the full dotted path is defined in any way.
+func (doc *Document) Defined(path string) bool {
+	return doc.defined[path]
+}
+
+// KeysEnd returns offset after the last part of the key.
+func KeysEnd(it unstable.Iterator) int {
+	var end int
+	for it.Next() {
+		raw := it.Node().Raw
+		end = int(raw.Offset + raw.Length)
+	}
+	return end
+}
+
+// Keys returns parts of the dotted key.
+func Keys(it unstable.Iterator) []string {
+	var keys []string
+	for it.Next() {
+		keys = append(keys, string(it.Node().Data))
+	}
+	return keys
+}
+
+// LineEnd returns offset of the end of line (before «\r\n» or «\n»), which contains offset.
+func (doc *Document) LineEnd(offset int) int {
+	i := bytes.IndexByte(doc.Src[offset:], '\n')
+	if i < 0 {
+		return len(doc.Src)
+	}
+	if i > 0 && doc.Src[offset+i-1] == '\r' {
+		i--
+	}
+	return offset + i
+}
+
+// Quoted returns s in quotes of the document.
+func (doc *Document) Quoted(s string) string {
+	q := string(doc.Quote)
+	return q + s + q
+}
+
+// Replace returns edit of string value, if its color is changed.
+func (doc *Document) Replace(r unstable.Range, c termcolor.Color) []Edit {
+	start, end := int(r.Offset), int(r.Offset+r.Length)
+	raw := doc.Src[start:end]
+	if old := strings.Trim(string(raw), `"'`); termcolor.HEX.MatchString(old) && termcolor.FromHEX(old).HEX() == c.HEX() {
+		return nil
+	}
+	q := string(raw[:1])
+	return []Edit{{Start: start, End: end, Text: q + c.HEX() + q}}
+}
+
+// Patch collects changes of the document.
+type Patch struct {
+	doc   *Document
+	edits []Edit
+	// Missing keys by table, in order of writing.
+	missing map[string][]string
+	tables  []string
+	// Text appended after the missing tables.
+	appended strings.Builder
+}
+
+// Patch returns empty patch of the document.
+func (doc *Document) Patch() *Patch {
+	return &Patch{doc: doc, missing: make(map[string][]string)}
+}
+
+// Set replaces string value of the key or adds it, if it is missing.
+// Nil colors are skipped.
+func (p *Patch) Set(table, key string, c termcolor.Color) {
+	if c.Nil() {
+		return
+	}
+	if r, ok := p.doc.values[table+"."+key]; ok {
+		p.Edit(p.doc.Replace(r, c)...)
+		return
+	}
+	p.Add(table, key+" = "+p.doc.Quoted(c.HEX()))
+}
+
+// Add adds the key/value line to the table.
+func (p *Patch) Add(table, kv string) {
+	if _, ok := p.missing[table]; !ok {
+		p.tables = append(p.tables, table)
+	}
+	p.missing[table] = append(p.missing[table], kv)
+}
+
+// Edit adds edits of the source.
+func (p *Patch) Edit(edits ...Edit) {
+	p.edits = append(p.edits, edits...)
+}
+
+// Append adds text to the end of the document, after the missing tables.
+func (p *Patch) Append(text string) {
+	p.appended.WriteString(text)
+}
+
+// Bytes returns the patched source. Missing keys are inserted after the last
+// key/value of their table, or appended with the table, if it isn't defined.
+func (p *Patch) Bytes() ([]byte, error) {
+	doc, edits := p.doc, slices.Clone(p.edits)
+	var appended strings.Builder
+	for _, table := range p.tables {
+		a, ok := doc.anchors[table]
+		switch {
+		case ok:
+			text := &strings.Builder{}
+			for _, kv := range p.missing[table] {
+				text.WriteByte('\n')
+				if a.prefix != "" {
+					text.WriteString(a.prefix + ".")
+				}
+				text.WriteString(kv)
+			}
+			end := doc.LineEnd(a.end)
+			edits = append(edits, Edit{Start: end, End: end, Text: text.String()})
+		case doc.defined[table]:
+			return nil, fmt.Errorf("%s: cannot add keys to inline table", table)
+		default:
+			appended.WriteString("\n[" + table + "]\n")
+			for _, kv := range p.missing[table] {
+				appended.WriteString(kv + "\n")
+			}
+		}
+	}
+	appended.WriteString(p.appended.String())
+
+	sort.SliceStable(edits, func(i, j int) bool {
+		return edits[i].Start < edits[j].Start
+	})
+	var out []byte
+	var pos int
+	for _, e := range edits {
+		out = append(out, doc.Src[pos:e.Start]...)
+		out = append(out, strings.ReplaceAll(e.Text, "\n", doc.eol)...)
+		pos = e.End
+	}
+	out = append(out, doc.Src[pos:]...)
+	if appended.Len() > 0 {
+		if len(out) > 0 && out[len(out)-1] != '\n' {
+			out = append(out, doc.eol...)
+		}
+		text := appended.String()
+		if len(out) == 0 {
+			text = strings.TrimPrefix(text, "\n")
+		}
+		out = append(out, strings.ReplaceAll(text, "\n", doc.eol)...)
+	}
+	return out, nil
+}
@@ -0,0 +1,92 @@
+package tomlpatch
+
+import (
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestPatch(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		set  func(p *Patch)
+		want string
+	}{
+		{
+			name: "replace and insert",
+			src:  "[colors] # palette\nbackground = '#000000' # bg\n\n[font]\nsize = 12\n",
+			set: func(p *Patch) {
+				p.Set("colors", "background", termcolor.FromHEX("#111111"))
+				p.Set("colors", "foreground", termcolor.FromHEX("#ffffff"))
+			},
+			want: "[colors] # palette\nbackground = '#111111' # bg\nforeground = '#ffffff'\n\n[font]\nsize = 12\n",
+		},
+		{
+			name: "dotted keys",
+			src:  "[colors]\nprimary.background = \"#000000\"\n",
+			set: func(p *Patch) {
+				p.Set("colors.primary", "foreground", termcolor.FromHEX("#ffffff"))
+			},
+			want: "[colors]\nprimary.background = \"#000000\"\nprimary.foreground = \"#ffffff\"\n",
+		},
+		{
+			name: "missing table and appended text",
+			src:  "[font]\nsize = 12",
+			set: func(p *Patch) {
+				p.Append("\n# end\n")
+				p.Set("colors", "background", termcolor.FromHEX("#000000"))
+			},
+			want: "[font]\nsize = 12\n\n[colors]\nbackground = '#000000'\n\n# end\n",
+		},
+		{
+			name: "CRLF",
+			src:  "[colors]\r\nbackground = '#000000'\r\n",
+			set: func(p *Patch) {
+				p.Set("colors", "foreground", termcolor.FromHEX("#ffffff"))
+				p.Set("colors.indexed", "16", termcolor.FromHEX("#222222"))
+			},
+			want: "[colors]\r\nbackground = '#000000'\r\nforeground = '#ffffff'\r\n\r\n[colors.indexed]\r\n16 = '#222222'\r\n",
+		},
+		{
+			name: "unchanged",
+			src:  "[colors]\nbackground = '#FFFFFF'\n",
+			set: func(p *Patch) {
+				p.Set("colors", "background", termcolor.FromHEX("#ffffff"))
+			},
+			want: "[colors]\nbackground = '#FFFFFF'\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := New([]byte(tt.src), "colors", '\'')
+			if err := doc.Parse(nil); err != nil {
+				t.Fatal("Parse():", err)
+			}
+			p := doc.Patch()
+			tt.set(p)
+			got, err := p.Bytes()
+			if err != nil {
+				t.Fatal("Bytes():", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Bytes() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchInlineTable(t *testing.T) {
+	doc := New([]byte("colors = { background = '#000000' }\n"), "colors", '\'')
+	if err := doc.Parse(nil); err != nil {
+		t.Fatal("Parse():", err)
+	}
+	p := doc.Patch()
+	p.Set("colors", "background", termcolor.FromHEX("#111111"))
+	if got, err := p.Bytes(); err != nil || string(got) != "colors = { background = '#111111' }\n" {
+		t.Errorf("Bytes() = %q, %v", got, err)
+	}
+	p.Set("colors", "foreground", termcolor.FromHEX("#ffffff"))
+	if _, err := p.Bytes(); err == nil {
+		t.Error("Bytes() adds key to inline table")
+	}
+}
@@ -0,0 +1,440 @@
+package iterm2
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("iterm2", &fileType{})
+}
+
+type fileType struct{}
+
+// Parse implements ftypes.FileType.
+// Input is XML plist of .itermcolors file.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	root, err := parsePlist(xml.NewDecoder(input))
+	if err != nil {
+		return nil, err
+	}
+	dict, ok := root.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("plist: unexpected root type %T", root)
+	}
+	cs := &colorScheme{entries: make(map[string]entry)}
+	for key, val := range dict {
+		comps, ok := val.(map[string]any)
+		if !ok || !strings.HasSuffix(key, " Color") {
+			continue
+		}
+		e, err := parseEntry(comps)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		cs.entries[key] = e
+		if n := ansiIndex(key); n >= 0 {
+			cs.indexed[n] = e.color
+		}
+	}
+	cs.background = cs.entries[backgroundKey].color
+	cs.foreground = cs.entries[foregroundKey].color
+	return cs, nil
+}
+
+const (
+	backgroundKey = "Background Color"
+	foregroundKey = "Foreground Color"
+)
+
+func ansiKey(n int) string {
+	return "Ansi " + strconv.Itoa(n) + " Color"
+}
+
+// ansiIndex returns index of «Ansi N Color» key or -1.
+func ansiIndex(key string) int {
+	s, ok := strings.CutPrefix(key, "Ansi ")
+	if !ok {
+		return -1
+	}
+	s, ok = strings.CutSuffix(s, " Color")
+	if !ok {
+		return -1
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 15 {
+		return -1
+	}
+	return n
+}
+
+// parsePlist decodes plist value: dict, array, string, real, integer or bool.
+func parsePlist(dec *xml.Decoder) (any, error) {
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local == "plist" {
+			continue
+		}
+		return parseValue(dec, start)
+	}
+}
+
+func parseValue(dec *xml.Decoder, start xml.StartElement) (any, error) {
+	switch start.Name.Local {
+	case "dict":
+		dict := make(map[string]any)
+		var key string
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				if tok.Name.Local == "key" {
+					if err := dec.DecodeElement(&key, &tok); err != nil {
+						return nil, err
+					}
+					continue
+				}
+				val, err := parseValue(dec, tok)
+				if err != nil {
+					return nil, fmt.Errorf("%s: %v", key, err)
+				}
+				dict[key] = val
+			case xml.EndElement:
+				return dict, nil
+			}
+		}
+	case "array":
+		var arr []any
+		for {
+			tok, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			switch tok := tok.(type) {
+			case xml.StartElement:
+				val, err := parseValue(dec, tok)
+				if err != nil {
+					return nil, err
+				}
+				arr = append(arr, val)
+			case xml.EndElement:
+				return arr, nil
+			}
+		}
+	case "true", "false":
+		return start.Name.Local == "true", dec.Skip()
+	}
+	var text string
+	if err := dec.DecodeElement(&text, &start); err != nil {
+		return nil, err
+	}
+	switch start.Name.Local {
+	case "real", "integer":
+		return strconv.ParseFloat(strings.TrimSpace(text), 64)
+	}
+	return text, nil
+}
+
+// Color entry of the profile.
+type entry struct {
+	color termcolor.Color
+	space string
+	alpha float64
+}
+
+const (
+	spaceSRGB       = "sRGB"
+	spaceP3         = "P3"
+	spaceCalibrated = "Calibrated"
+)
+
+func parseEntry(comps map[string]any) (entry, error) {
+	// Presets without color space are made by old iTerm2 versions, which use calibrated colors.
+	e := entry{space: spaceCalibrated, alpha: 1}
+	if s, ok := comps["Color Space"].(string); ok {
+		e.space = s
+	}
+	switch e.space {
+	case spaceSRGB, spaceP3, spaceCalibrated:
+	default:
+		return e, fmt.Errorf("unsupported color space %q", e.space)
+	}
+	if a, ok := comps["Alpha Component"].(float64); ok {
+		e.alpha = a
+	}
+	var rgb [3]float64
+	for i, name := range []string{"Red", "Green", "Blue"} {
+		v, ok := comps[name+" Component"].(float64)
+		if !ok {
+			return e, errors.New("missing " + name + " Component")
+		}
+		rgb[i] = v
+	}
+	switch e.space {
+	case spaceP3:
+		rgb = p3ToSRGB(rgb)
+	case spaceCalibrated:
+		rgb = calibratedToSRGB(rgb)
+	}
+	e.color = termcolor.FromHEX(fmt.Sprintf("#%02x%02x%02x", to255(rgb[0]), to255(rgb[1]), to255(rgb[2])))
+	return e, nil
+}
+
+func (e entry) components() map[string]any {
+	r, g, b := e.color.RGB()
+	rgb := [3]float64{float64(r) / 255, float64(g) / 255, float64(b) / 255}
+	switch e.space {
+	case spaceP3:
+		rgb = srgbToP3(rgb)
+	case spaceCalibrated:
+		rgb = srgbToCalibrated(rgb)
+	}
+	return map[string]any{
+		"Red Component":   rgb[0],
+		"Green Component": rgb[1],
+		"Blue Component":  rgb[2],
+		"Alpha Component": e.alpha,
+		"Color Space":     e.space,
+	}
+}
+
+func to255(v float64) uint8 {
+	return uint8(math.Round(math.Max(0, math.Min(1, v)) * 255))
+}
+
+// Display P3 and sRGB share transfer function and white point,
+// so conversion is made by matrix in linear space.
+var (
+	p3ToSRGBMatrix = [3][3]float64{
+		{1.2249401, -0.2249404, 0},
+		{-0.0420569, 1.0420571, 0},
+		{-0.0196376, -0.0786361, 1.0982735},
+	}
+	srgbToP3Matrix = [3][3]float64{
+		{0.8224621, 0.1775380, 0},
+		{0.0331941, 0.9668058, 0},
+		{0.0170827, 0.0723974, 0.9105199},
+	}
+)
+
+// Calibrated colors are in macOS Generic RGB space: sRGB white point,
+// own primaries and 1.8 gamma.
+const calibratedGamma = 1.8
+
+var (
+	calibratedToSRGBMatrix = [3][3]float64{
+		{1.0252525, -0.0265475, 0.0012951},
+		{0.0193935, 0.9480280, 0.0325785},
+		{-0.0017695, -0.0014423, 1.0032119},
+	}
+	srgbToCalibratedMatrix = [3][3]float64{
+		{0.9748495, 0.0272954, -0.0021449},
+		{-0.0200003, 1.0542090, -0.0342088},
+		{0.0016907, 0.0015638, 0.9967455},
+	}
+)
+
+func calibratedToSRGB(rgb [3]float64) (out [3]float64) {
+	var lin [3]float64
+	for i, v := range rgb {
+		lin[i] = math.Pow(math.Max(0, v), calibratedGamma)
+	}
+	for i, v := range multiply(calibratedToSRGBMatrix, lin) {
+		out[i] = delinearize(v)
+	}
+	return
+}
+
+func srgbToCalibrated(rgb [3]float64) (out [3]float64) {
+	var lin [3]float64
+	for i, v := range rgb {
+		lin[i] = linearize(v)
+	}
+	for i, v := range multiply(srgbToCalibratedMatrix, lin) {
+		out[i] = math.Pow(math.Max(0, v), 1/calibratedGamma)
+	}
+	return
+}
+
+func multiply(m [3][3]float64, v [3]float64) (out [3]float64) {
+	for i := range 3 {
+		out[i] = m[i][0]*v[0] + m[i][1]*v[1] + m[i][2]*v[2]
+	}
+	return
+}
+
+func p3ToSRGB(rgb [3]float64) [3]float64 {
+	return convert(p3ToSRGBMatrix, rgb)
+}
+
+func srgbToP3(rgb [3]float64) [3]float64 {
+	return convert(srgbToP3Matrix, rgb)
+}
+
+func convert(m [3][3]float64, rgb [3]float64) (out [3]float64) {
+	var lin [3]float64
+	for i, v := range rgb {
+		lin[i] = linearize(v)
+	}
+	for i, v := range multiply(m, lin) {
+		out[i] = delinearize(v)
+	}
+	return
+}
+
+func linearize(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func delinearize(v float64) float64 {
+	if v <= 0.0031308 {
+		return 12.92 * v
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if !bytes.Contains(head, []byte("<plist")) {
+		return 0
+	}
+	if bytes.Contains(head, []byte("<key>Ansi 0 Color</key>")) {
+		return 100
+	}
+	return 30
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	return ext == ".itermcolors"
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := &colorScheme{
+		entries:    make(map[string]entry),
+		background: src.Background(),
+		foreground: src.Foreground(),
+	}
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// OutputFormat implements filetype.Exporter.
+func (f *fileType) OutputFormat() string {
+	return "Dynamic Profile JSON"
+}
+
+var _ filetype.Exporter = (*fileType)(nil)
+
+type colorScheme struct {
+	entries    map[string]entry
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// profileName is the name (and guid) of written dynamic profile.
+const profileName = "cterm256"
+
+// Write implements termcolor.Table.
+// Output is iTerm2 Dynamic Profile JSON with all colors of the source file.
+// Colors 16-255 can't be stored in profile, see Unsupported.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	set := func(key string, c termcolor.Color) {
+		if c.Nil() {
+			return
+		}
+		e, ok := cs.entries[key]
+		if !ok {
+			e = entry{space: spaceSRGB, alpha: 1}
+		}
+		e.color = c
+		cs.entries[key] = e
+	}
+	for n, c := range cs.indexed[:16] {
+		set(ansiKey(n), c)
+	}
+	set(backgroundKey, cs.background)
+	set(foregroundKey, cs.foreground)
+
+	profile := map[string]any{
+		"Name": profileName,
+		"Guid": profileName,
+	}
+	for key, e := range cs.entries {
+		profile[key] = e.components()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"Profiles": []any{profile}})
+}
+
+// Unsupported implements filetype.Limited.
+func (cs *colorScheme) Unsupported() []int {
+	var indexes []int
+	for n := 16; n < 256; n++ {
+		if !cs.indexed[n].Nil() {
+			indexes = append(indexes, n)
+		}
+	}
+	return indexes
+}
+
+var _ filetype.Limited = (*colorScheme)(nil)
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
@@ -0,0 +1,133 @@
+package iterm2
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	in, err := os.Open("testdata/scheme.itermcolors")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		in.Close()
+	})
+	cs, err := new(fileType).Parse(in)
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#1d1f21"},
+		{name: "color 0", color: cs.Color(0), hex: "#282a2e"},
+		{name: "color 1", color: cs.Color(1), hex: "#a54242"},
+		{name: "P3 green", color: cs.Color(2), hex: "#00ff00"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+	if got := cs.Foreground().HEX(); got != "#c4c8c6" {
+		t.Errorf("foreground.HEX() = %s, want #c4c8c6", got)
+	}
+
+	for n, hex := range []string{"#de935f", "#5f819d", "#85678f", "#5e8d87", "#707880"} {
+		cs.SetColor(n+3, termcolor.FromHEX(hex))
+	}
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	var got struct {
+		Profiles []map[string]json.RawMessage
+	}
+	if err := json.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatal("written profile is invalid:", err)
+	}
+	if len(got.Profiles) != 1 {
+		t.Fatalf("written %d profiles, want 1", len(got.Profiles))
+	}
+	profile := got.Profiles[0]
+	for _, key := range []string{"Name", "Guid", "Ansi 15 Color", "Cursor Color"} {
+		if _, ok := profile[key]; !ok {
+			t.Errorf("%q is missing in written profile", key)
+		}
+	}
+	var fg map[string]any
+	if err := json.Unmarshal(profile[foregroundKey], &fg); err != nil {
+		t.Fatal(err)
+	}
+	if fg["Color Space"] != spaceP3 {
+		t.Errorf("foreground color space = %v, want %s", fg["Color Space"], spaceP3)
+	}
+	if n := len(cs.(*colorScheme).Unsupported()); n != 240 {
+		t.Errorf("Unsupported() returns %d colors, want 240", n)
+	}
+}
+
+func TestP3RoundTrip(t *testing.T) {
+	for _, rgb := range [][3]float64{{0, 0, 0}, {1, 1, 1}, {0.2, 0.6, 0.4}, {0.9, 0.1, 0.3}} {
+		got := p3ToSRGB(srgbToP3(rgb))
+		for i := range rgb {
+			if math.Abs(got[i]-rgb[i]) > 1e-5 {
+				t.Errorf("p3ToSRGB(srgbToP3(%v)) = %v", rgb, got)
+				break
+			}
+		}
+	}
+}
+
+func TestParseEntryColorSpace(t *testing.T) {
+	comps := func(space string, r, g, b float64) map[string]any {
+		m := map[string]any{"Red Component": r, "Green Component": g, "Blue Component": b}
+		if space != "" {
+			m["Color Space"] = space
+		}
+		return m
+	}
+	for _, tt := range []struct {
+		name  string
+		comps map[string]any
+		want  string
+		space string
+	}{
+		{name: "sRGB", comps: comps(spaceSRGB, 1, 0, 0), want: "#ff0000", space: spaceSRGB},
+		{name: "calibrated", comps: comps(spaceCalibrated, 0.8, 0.4, 0.2), want: "#d77b42", space: spaceCalibrated},
+		{name: "calibrated gray", comps: comps(spaceCalibrated, 0.5, 0.5, 0.5), want: "#929292", space: spaceCalibrated},
+		{name: "missing space", comps: comps("", 0.5, 0.5, 0.5), want: "#929292", space: spaceCalibrated},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := parseEntry(tt.comps)
+			if err != nil {
+				t.Fatal("parseEntry():", err)
+			}
+			if got := e.color.HEX(); got != tt.want || e.space != tt.space {
+				t.Errorf("parseEntry() = %s in %s, want %s in %s", got, e.space, tt.want, tt.space)
+			}
+			// Written components are converted back to the source space.
+			back := e.components()
+			for _, name := range []string{"Red", "Green", "Blue"} {
+				key := name + " Component"
+				if d := math.Abs(back[key].(float64) - tt.comps[key].(float64)); d > 0.01 {
+					t.Errorf("%s = %0.3f, want %0.3f", key, back[key], tt.comps[key])
+				}
+			}
+		})
+	}
+	if _, err := parseEntry(comps("Device", 1, 1, 1)); err == nil {
+		t.Error("parseEntry() of unknown color space succeeded")
+	}
+}
@@ -2,10 +2,15 @@ package kitty
 
 import (
 	"bufio"
-	"bytes"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -20,107 +25,205 @@ func init() {
 type fileType struct{}
 
 // Parse implements ftypes.FileType.
+// Include directives aren't followed, because there is no directory to resolve them.
 func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
-	var err error
-	cs := &colorScheme{named: make(map[string]termcolor.Color)}
+	cs := newColorScheme()
+	if err := cs.parse("", input, nil); err != nil {
+		return nil, err
+	}
+	cs.setDefaults()
+	return cs, nil
+}
+
+// ParseFile implements filetype.IncludeParser.
+// Included files are parsed in place of include and globinclude directives,
+// so the later definition of the color takes precedence, as in kitty.
+func (f *fileType) ParseFile(name string, input io.Reader) (termcolor.Table, error) {
+	cs := newColorScheme()
+	name = filepath.Clean(name)
+	if err := cs.parse(name, input, []string{name}); err != nil {
+		return nil, err
+	}
+	cs.setDefaults()
+	return cs, nil
+}
+
+func newColorScheme() *colorScheme {
+	return &colorScheme{
+		named:   make(map[string]termcolor.Color),
+		defs:    make(map[string]int),
+		derived: make(map[string]string),
+	}
+}
+
+// parse parses lines of the file. Chain contains names of the including files, if they are followed.
+func (cs *colorScheme) parse(name string, input io.Reader, chain []string) error {
+	// The same file could be included several times, but its lines are stored once.
+	idx := slices.IndexFunc(cs.sources, func(src *source) bool { return src.name == name })
+	src := &source{name: name}
+	if idx < 0 {
+		idx = len(cs.sources)
+		cs.sources = append(cs.sources, src)
+	}
 	scan := bufio.NewScanner(input)
 	var ln int
 	for scan.Scan() {
-		line := ltrim(scan.Bytes())
-		if l := len(line); l == 0 || line[0] == '#' {
-			if l > 0 {
-				cs.raw = append(cs.raw, line)
-			}
-			continue
+		ln++
+		l, err := parseLine(scan.Text())
+		if err != nil {
+			return fmt.Errorf("%d line: %v", ln, err)
 		}
-		err = scanLine(bytes.NewReader(line), cs)
-		if errors.Is(err, errCannotParseLine) {
-			cs.raw = append(cs.raw, line)
-		} else if err != nil {
-			return nil, fmt.Errorf("%d line: %v", ln, err)
+		switch {
+		case l.key == "":
+			if chain != nil {
+				if err := cs.include(l.raw, chain); err != nil {
+					return fmt.Errorf("%d line: %v", ln, err)
+				}
+			}
+		case l.index >= 0:
+			cs.indexed[l.index] = l.color
+			cs.defs[l.key] = idx
+		default:
+			cs.named[l.key] = l.color
+			cs.defs[l.key] = idx
 		}
-		ln++
+		src.lines = append(src.lines, l)
 	}
-	if err := scan.Err(); err != nil {
-		return nil, err
+	return scan.Err()
+}
+
+var includeLine = regexp.MustCompile(`^\s*(include|globinclude)\s+(.*?)\s*$`)
+
+// include parses files of the include directive, if the line is.
+// Missing files are skipped, as kitty does.
+func (cs *colorScheme) include(raw string, chain []string) error {
+	m := includeLine.FindStringSubmatch(raw)
+	if m == nil {
+		return nil
 	}
-	if c, f := cs.named["cursor"], cs.named["foreground"]; c.Nil() && !f.Nil() {
-		cs.named["cursor"] = f
+	name := filetype.IncludePath(os.ExpandEnv(m[2]), filepath.Dir(chain[len(chain)-1]))
+	names := []string{name}
+	if m[1] == "globinclude" {
+		var err error
+		if names, err = filepath.Glob(name); err != nil {
+			return fmt.Errorf("%s: %v", m[2], err)
+		}
 	}
-	if c, b := cs.named["cursor_text_color"], cs.named["background"]; c.Nil() && !b.Nil() {
-		cs.named["cursor_text_color"] = b
+	for _, name := range names {
+		if slices.Contains(chain, name) {
+			return fmt.Errorf("%s: recursive include", name)
+		}
+		file, err := os.Open(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		err = cs.parse(name, file, append(slices.Clip(chain), name))
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
 	}
-	return cs, nil
+	return nil
 }
 
-func ltrim(s []byte) []byte {
-	for i, c := range s {
-		switch c {
-		case ' ', '\t':
-			continue
-		default:
-			return s[i:]
+// setDefaults sets cursor colors as kitty does, if they aren't defined,
+// and keeps parsed colors to find changed ones on writing.
+func (cs *colorScheme) setDefaults() {
+	for key, from := range map[string]string{"cursor": "foreground", "cursor_text_color": "background"} {
+		if c, f := cs.named[key], cs.named[from]; c.Nil() && !f.Nil() {
+			cs.named[key] = f
+			cs.derived[key] = from
 		}
 	}
-	return nil
+	cs.parsed = colors{named: maps.Clone(cs.named), indexed: cs.indexed}
 }
 
-type keyword int
+// Names of colors, in order of writing.
+var namedColors = []string{
+	"foreground",
+	"background",
+	"cursor",
+	"cursor_text_color",
+}
 
-const (
-	unknownKeyword keyword = iota
-	namedColor
-	indexedColor
-)
+// Line of the config. Color lines are split to be updated in place.
+type line struct {
+	raw string
 
-var errCannotParseLine = errors.New("cannot parse line")
+	// Color lines: raw is prefix + value + suffix.
+	key    string
+	index  int // Index of colorN or -1 for named colors.
+	color  termcolor.Color
+	prefix string
+	value  string
+	suffix string
+}
 
-func scanLine(line io.Reader, cs *colorScheme) (err error) {
-	scan := bufio.NewScanner(line)
-	scan.Split(bufio.ScanWords)
-	if !scan.Scan() {
-		return scan.Err()
+func (l line) String() string {
+	if l.key == "" {
+		return l.raw
 	}
-	switch word := scan.Text(); word {
-	case "foreground",
-		"background",
-		"cursor",
-		"cursor_text_color":
-		c, err := scanColorValue(scan)
-		if err != nil {
-			return fmt.Errorf("%q: %w", word, err)
-		}
-		cs.named[word] = c
-	default:
-		if len(word) < 6 || !strings.HasPrefix(word, "color") {
-			return errCannotParseLine
+	return l.prefix + l.value + l.suffix
+}
+
+var errMissingColorValue = errors.New("missing color value")
+
+// parseLine parses color line. Other lines are kept as raw ones.
+func parseLine(raw string) (line, error) {
+	l := line{raw: raw, index: -1}
+	trimmed := strings.TrimLeft(raw, " \t")
+	if trimmed == "" || trimmed[0] == '#' {
+		return l, nil
+	}
+	end := strings.IndexAny(trimmed, " \t")
+	if end < 0 {
+		end = len(trimmed)
+	}
+	key := trimmed[:end]
+	index := -1
+	if !slices.Contains(namedColors, key) {
+		if len(key) < 6 || !strings.HasPrefix(key, "color") {
+			return l, nil
 		}
-		n, err := strconv.Atoi(word[5:])
+		n, err := strconv.Atoi(key[5:])
 		if err != nil {
-			return fmt.Errorf("%q: parse number: %v", word, err)
+			return l, fmt.Errorf("%q: parse number: %v", key, err)
 		}
-		c, err := scanColorValue(scan)
-		if err != nil {
-			return fmt.Errorf("%q: %w", word, err)
+		if n < 0 || n > 255 {
+			return l, fmt.Errorf("%q: number out of bounds", key)
 		}
-		cs.indexed[n] = c
+		index = n
 	}
-	return scan.Err()
+	rest := trimmed[end:]
+	value := strings.TrimLeft(rest, " \t")
+	if value == "" {
+		return l, fmt.Errorf("%q: %w", key, errMissingColorValue)
+	}
+	if end := strings.IndexAny(value, " \t"); end >= 0 {
+		l.suffix = value[end:]
+		value = value[:end]
+	}
+	// Color names and other values are kept as is.
+	if !termcolor.HEX.MatchString(value) {
+		return line{raw: raw, index: -1}, nil
+	}
+	l.key, l.index, l.value = key, index, value
+	l.color = termcolor.FromHEX(value)
+	l.prefix = raw[:len(raw)-len(value)-len(l.suffix)]
+	return l, nil
 }
 
-var (
-	errMissingColorValue = errors.New("missing color value")
-)
+var colorLine = regexp.MustCompile(`(?m)^\s*(color\d{1,3}|foreground|background|cursor|selection_(fore|back)ground)[ \t]+#?[0-9a-fA-F]{6}\s*$`)
 
-func scanColorValue(scan *bufio.Scanner) (termcolor.Color, error) {
-	if !scan.Scan() {
-		return termcolor.Color{}, errMissingColorValue
-	}
-	v := scan.Text()
-	if !termcolor.HEX.MatchString(v) {
-		return termcolor.Color{}, errCannotParseLine
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if colorLine.Match(head) {
+		return 80
 	}
-	return termcolor.FromHEX(scan.Text()), nil
+	return 0
 }
 
 // Support implements ftypes.FileType.
@@ -133,36 +236,176 @@ func (f *fileType) Support(name string, ext string) bool {
 
 var _ filetype.FileType = (*fileType)(nil)
 
+var _ filetype.Detector = (*fileType)(nil)
+
+var _ filetype.IncludeParser = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := newColorScheme()
+	cs.sources = []*source{{}}
+	for name, c := range map[string]termcolor.Color{
+		"background":        src.Background(),
+		"foreground":        src.Foreground(),
+		"cursor":            src.Foreground(),
+		"cursor_text_color": src.Background(),
+	} {
+		if !c.Nil() {
+			cs.named[name] = c
+		}
+	}
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// Config file: the main one or included.
+type source struct {
+	name  string
+	lines []line
+}
+
 type colorScheme struct {
-	raw     [][]byte
+	sources []*source         // The main file is the first.
+	defs    map[string]int    // Source of the effective definition by color key.
+	derived map[string]string // Keys of colors, which defaults are derived from.
+	parsed  colors
+	named   map[string]termcolor.Color
+	indexed [256]termcolor.Color
+}
+
+// Colors of the scheme, as they are parsed.
+type colors struct {
 	named   map[string]termcolor.Color
 	indexed [256]termcolor.Color
 }
 
+// color returns color of the key.
+func (c *colors) color(key string) termcolor.Color {
+	if s, ok := strings.CutPrefix(key, "color"); ok {
+		if n, err := strconv.Atoi(s); err == nil {
+			return c.indexed[n]
+		}
+	}
+	return c.named[key]
+}
+
+// Markers of the block with colors, which were missing in the source.
+const (
+	blockBegin = "# BEGIN cterm256 generated colors"
+	blockEnd   = "# END cterm256 generated colors"
+)
+
 // Write implements termcolor.Table.
+// Color lines are updated in place. Missing colors are added to the marked block,
+// which is appended to the end of file once and is updated on the next runs.
+// Changed colors of included files are written to the block too, so the main file overrides them.
 func (cs *colorScheme) Write(w termcolor.Writer) error {
+	_, err := w.WriteString(cs.patch(0, func(key string) bool {
+		return cs.block(key, false)
+	}))
+	return err
+}
+
+// block reports whether color of the key is written to the generated block of the main file.
+// Colors of included files are written only if they are changed, so the included files
+// (like themes) still take effect. With files set, they are written to their files instead.
+func (cs *colorScheme) block(key string, files bool) bool {
+	src := key
+	if from, ok := cs.derived[key]; ok {
+		src = from
+	}
+	idx, ok := cs.defs[src]
+	switch {
+	case !ok:
+		return true
+	case src == key && (idx == 0 || files):
+		return false
+	case idx == 0:
+		// Default of the main file color.
+		return true
+	}
+	c, parsed := (&colors{named: cs.named, indexed: cs.indexed}).color(key), cs.parsed.color(key)
+	return !c.Nil() && (parsed.Nil() || c.HEX() != parsed.HEX())
+}
+
+// Files implements filetype.MultiFile.
+func (cs *colorScheme) Files() (map[string][]byte, error) {
+	files := make(map[string][]byte)
+	for i, src := range cs.sources {
+		out := cs.patch(i, func(key string) bool {
+			return i == 0 && cs.block(key, true)
+		})
+		s := &strings.Builder{}
+		for _, l := range src.lines {
+			s.WriteString(l.raw)
+			s.WriteByte('\n')
+		}
+		if out != s.String() {
+			files[src.name] = []byte(out)
+		}
+	}
+	return files, nil
+}
+
+// patch returns content of the source with updated colors, which effective definitions it has.
+// Colors, for which missing returns true, are written to the marked block.
+func (cs *colorScheme) patch(idx int, missing func(key string) bool) string {
+	lines := slices.Clone(cs.sources[idx].lines)
+	end := -1
+	for i, l := range lines {
+		if strings.TrimSpace(l.raw) == blockEnd {
+			end = i
+		}
+		if l.key == "" || cs.defs[l.key] != idx {
+			continue
+		}
+		c := cs.named[l.key]
+		if l.index >= 0 {
+			c = cs.indexed[l.index]
+		}
+		if !c.Nil() && c.HEX() != l.color.HEX() {
+			lines[i].value = c.HEX()
+		}
+	}
+	var block []string
+	for _, name := range namedColors {
+		if c := cs.named[name]; !c.Nil() && missing(name) {
+			block = append(block, name+" "+c.HEX())
+		}
+	}
+	for n, c := range cs.indexed {
+		if name := "color" + strconv.Itoa(n); !c.Nil() && missing(name) {
+			block = append(block, name+" "+c.HEX())
+		}
+	}
 	s := &strings.Builder{}
-	for _, r := range cs.raw {
-		s.Write(r)
+	for i, l := range lines {
+		if i == end {
+			writeLines(s, block)
+		}
+		s.WriteString(l.String())
 		s.WriteByte('\n')
 	}
-	for n, c := range cs.named {
-		s.WriteString(n)
-		writeColor(s, c)
-	}
-	for i, c := range cs.indexed {
-		s.WriteString("color")
-		s.WriteString(strconv.Itoa(i))
-		writeColor(s, c)
+	if end < 0 && len(block) > 0 {
+		if n := len(lines); n > 0 && strings.TrimSpace(lines[n-1].raw) != "" {
+			s.WriteByte('\n')
+		}
+		s.WriteString(blockBegin + "\n")
+		writeLines(s, block)
+		s.WriteString(blockEnd + "\n")
 	}
-	_, err := w.WriteString(s.String())
-	return err
+	return s.String()
 }
 
-func writeColor(s *strings.Builder, c termcolor.Color) {
-	s.WriteRune(' ')
-	s.WriteString(c.HEX())
-	s.WriteRune('\n')
+func writeLines(s *strings.Builder, lines []string) {
+	for _, l := range lines {
+		s.WriteString(l)
+		s.WriteByte('\n')
+	}
 }
 
 // SetColor implements termcolor.Table.
@@ -189,3 +432,5 @@ func (cs *colorScheme) Foreground() termcolor.Color {
 }
 
 var _ termcolor.Table = (*colorScheme)(nil)
+
+var _ filetype.MultiFile = (*colorScheme)(nil)
@@ -0,0 +1,149 @@
+package kitty
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// rewrite parses and writes src, with generation if gen is set.
+func rewrite(t *testing.T, src string, gen bool) string {
+	t.Helper()
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	if gen {
+		if err := termcolor.Generate(cs, io.Discard); err != nil {
+			t.Fatal("Generate():", err)
+		}
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	return out.String()
+}
+
+func TestWriteInPlace(t *testing.T) {
+	src, err := os.ReadFile("testdata/theme.conf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := rewrite(t, string(src), true)
+	srcLines := strings.Split(strings.TrimSuffix(string(src), "\n"), "\n")
+	gotLines := strings.Split(first, "\n")
+	for i, want := range srcLines {
+		if strings.HasPrefix(want, "color") || strings.HasPrefix(want, "foreground") {
+			// Colors could be changed by generation, but not moved.
+			if key := strings.Fields(want)[0]; !strings.HasPrefix(gotLines[i], key+" ") {
+				t.Errorf("line %d = %q, want %s color", i+1, gotLines[i], key)
+			}
+			continue
+		}
+		if gotLines[i] != want {
+			t.Errorf("line %d = %q, want %q", i+1, gotLines[i], want)
+		}
+	}
+	if n := strings.Count(first, blockBegin); n != 1 {
+		t.Errorf("%d generated blocks, want 1", n)
+	}
+	for _, s := range []string{"\ncursor #", "\ncolor16 #", "\ncolor255 #"} {
+		if !strings.Contains(first, s) {
+			t.Errorf("output has no %q", s)
+		}
+	}
+	if second := rewrite(t, first, false); second != first {
+		t.Errorf("rewrite changed the output:\n%s", second)
+	}
+}
+
+func TestParseFileIncludes(t *testing.T) {
+	const name = "testdata/include/kitty.conf"
+	in, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	cs, err := new(fileType).ParseFile(name, in)
+	if err != nil {
+		t.Fatal("ParseFile():", err)
+	}
+	for n, want := range map[int]string{0: "#282a2e", 1: "#ff0000", 2: "#8c9440"} {
+		if got := cs.Color(n).HEX(); got != want {
+			t.Errorf("Color(%d) = %s, want %s", n, got, want)
+		}
+	}
+	if got := cs.Background().HEX(); got != "#1d1f21" {
+		t.Errorf("Background() = %s, want #1d1f21", got)
+	}
+
+	for n, hex := range []string{"#000000", "#111111", "#222222", "#333333"} {
+		cs.SetColor(n, termcolor.FromHEX(hex))
+	}
+	files, err := cs.(*colorScheme).Files()
+	if err != nil {
+		t.Fatal("Files():", err)
+	}
+	for name, want := range map[string]string{
+		"testdata/include/kitty.conf": "# Main config\nfont_size 12\ninclude current-theme.conf\nglobinclude colors/*.conf\ninclude missing.conf\ncolor1 #111111\n\n" +
+			blockBegin + "\ncolor3 #333333\n" + blockEnd + "\n",
+		"testdata/include/current-theme.conf": "foreground #c5c8c6\nbackground #1d1f21\ncursor #c5c8c6\ncursor_text_color #1d1f21\ncolor0 #000000\ncolor1 #a54242\n",
+		"testdata/include/colors/green.conf":  "color2 #222222\n",
+	} {
+		if got := string(files[name]); got != want {
+			t.Errorf("Files()[%s] =\n%s\nwant\n%s", name, got, want)
+		}
+	}
+	if len(files) != 3 {
+		t.Errorf("Files() returns %d files, want 3", len(files))
+	}
+
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	for _, s := range []string{"\ncolor1 #111111\n", "\ncolor0 #000000\n", "\ncolor2 #222222\n"} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("Write() output has no %q:\n%s", s, out)
+		}
+	}
+	// Unchanged colors of included files must not be pinned in the main file.
+	for _, s := range []string{"foreground", "background", "cursor", "\ncolor4 "} {
+		if strings.Contains(out.String(), s) {
+			t.Errorf("Write() output has %q:\n%s", s, out)
+		}
+	}
+}
+
+func TestWriteIncludesUnchanged(t *testing.T) {
+	const name = "testdata/include/kitty.conf"
+	src, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := new(fileType).ParseFile(name, bytes.NewReader(src))
+	if err != nil {
+		t.Fatal("ParseFile():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	if out.String() != string(src) {
+		t.Errorf("Write() without changes =\n%s\nwant\n%s", out, src)
+	}
+
+	cs.SetColor(0, termcolor.FromHEX("#000000"))
+	out.Reset()
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	if want := "\n" + blockBegin + "\ncolor0 #000000\n" + blockEnd + "\n"; !strings.HasSuffix(out.String(), want) {
+		t.Errorf("Write() =\n%s\nwant suffix\n%s", out, want)
+	}
+}
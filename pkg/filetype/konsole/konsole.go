@@ -0,0 +1,223 @@
+package konsole
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/filetype/internal/ini"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("konsole", &fileType{})
+}
+
+type fileType struct{}
+
+const (
+	sectionBackground = "Background"
+	sectionForeground = "Foreground"
+	keyColor          = "Color"
+)
+
+// Parse implements ftypes.FileType.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	file, err := ini.Parse(input)
+	if err != nil {
+		return nil, err
+	}
+	cs := &colorScheme{file: file}
+	for _, section := range file.Sections() {
+		n := colorIndex(section)
+		var dst *termcolor.Color
+		switch {
+		case n >= 0:
+			dst = &cs.indexed[n]
+		case section == sectionBackground:
+			dst = &cs.background
+		case section == sectionForeground:
+			dst = &cs.foreground
+		default:
+			continue
+		}
+		val, ok := file.Get(section, keyColor)
+		if !ok {
+			continue
+		}
+		c, hex, err := parseColor(val)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %v", section, keyColor, err)
+		}
+		cs.hex = cs.hex || hex
+		*dst = c
+	}
+	return cs, nil
+}
+
+// parseColor parses «r,g,b» decimal triplet or HEX value.
+func parseColor(val string) (c termcolor.Color, hex bool, err error) {
+	if termcolor.HEX.MatchString(val) {
+		return termcolor.FromHEX(val), true, nil
+	}
+	parts := strings.Split(val, ",")
+	if len(parts) != 3 {
+		return c, false, fmt.Errorf("unsupported color value %q", val)
+	}
+	var rgb [3]uint8
+	for i, s := range parts {
+		v, err := strconv.ParseUint(strings.TrimSpace(s), 10, 8)
+		if err != nil {
+			return c, false, fmt.Errorf("unsupported color value %q", val)
+		}
+		rgb[i] = uint8(v)
+	}
+	return termcolor.FromHEX(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2])), false, nil
+}
+
+// colorIndex returns palette index of the section or -1.
+// Faint colors aren't the part of the palette.
+func colorIndex(section string) int {
+	s, ok := strings.CutPrefix(section, "Color")
+	if !ok {
+		return -1
+	}
+	offset := 0
+	if s, ok = strings.CutSuffix(s, "Intense"); ok {
+		offset = 8
+	}
+	if n, err := strconv.Atoi(s); err == nil && len(s) == 1 && n < 8 {
+		return n + offset
+	}
+	return -1
+}
+
+// colorSection returns section of the palette index.
+func colorSection(n int) string {
+	if n < 8 {
+		return "Color" + strconv.Itoa(n)
+	}
+	return "Color" + strconv.Itoa(n-8) + "Intense"
+}
+
+var sectionLine = regexp.MustCompile(`(?m)^\s*\[(Color[0-7](Intense|Faint)?|Background|Foreground)\]`)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if sectionLine.Match(head) {
+		return 90
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	return ext == ".colorscheme"
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	table, err := f.Parse(strings.NewReader(template))
+	if err != nil {
+		return nil, err
+	}
+	cs := table.(*colorScheme)
+	cs.background, cs.foreground = src.Background(), src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// template of the encoded color scheme.
+const template = `[General]
+Description=cterm256
+Opacity=1
+`
+
+type colorScheme struct {
+	file       *ini.File
+	hex        bool // Whether source uses HEX values instead of triplets.
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// format returns color value in the format of source file.
+func (cs *colorScheme) format(c termcolor.Color) string {
+	if cs.hex {
+		return c.HEX()
+	}
+	r, g, b := c.RGB()
+	return fmt.Sprintf("%d,%d,%d", r, g, b)
+}
+
+// Write implements termcolor.Table.
+// Colors 16-255 can't be stored in color scheme, see Unsupported.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	set := func(section string, c termcolor.Color) {
+		if c.Nil() {
+			return
+		}
+		if val, ok := cs.file.Get(section, keyColor); ok {
+			if old, _, err := parseColor(val); err == nil && old.HEX() == c.HEX() {
+				return
+			}
+		}
+		cs.file.Set(section, keyColor, cs.format(c))
+	}
+	set(sectionBackground, cs.background)
+	set(sectionForeground, cs.foreground)
+	for n, c := range cs.indexed[:16] {
+		set(colorSection(n), c)
+	}
+	_, err := cs.file.WriteTo(w)
+	return err
+}
+
+// Unsupported implements filetype.Limited.
+func (cs *colorScheme) Unsupported() []int {
+	var indexes []int
+	for n := 16; n < 256; n++ {
+		if !cs.indexed[n].Nil() {
+			indexes = append(indexes, n)
+		}
+	}
+	return indexes
+}
+
+var _ filetype.Limited = (*colorScheme)(nil)
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
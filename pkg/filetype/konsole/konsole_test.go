@@ -0,0 +1,66 @@
+package konsole
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	in, err := os.Open("testdata/Solarized.colorscheme")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		in.Close()
+	})
+	cs, err := new(fileType).Parse(in)
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#002b36"},
+		{name: "foreground", color: cs.Foreground(), hex: "#839496"},
+		{name: "color 1", color: cs.Color(1), hex: "#dc322f"},
+		{name: "color 9", color: cs.Color(9), hex: "#cb4b16"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+	if !cs.Color(15).Nil() {
+		t.Errorf("color 15 = %s, want nil", cs.Color(15))
+	}
+
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	for _, s := range []string{"[Color7Faint]\nColor=238,232,213", "Description=Solarized", "[Color7Intense]\nColor="} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("written file has no %q", s)
+		}
+	}
+	got, err := new(fileType).Parse(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal("written file is invalid:", err)
+	}
+	for n := range 16 {
+		if got.Color(n).HEX() != cs.Color(n).HEX() {
+			t.Errorf("written color %d = %s, want %s", n, got.Color(n).HEX(), cs.Color(n).HEX())
+		}
+	}
+	if n := len(cs.(*colorScheme).Unsupported()); n != 240 {
+		t.Errorf("Unsupported() returns %d colors, want 240", n)
+	}
+}
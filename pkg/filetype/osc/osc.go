@@ -0,0 +1,135 @@
+// Package osc writes color table as OSC escape sequences,
+// which change colors of the running terminal.
+package osc
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// Wrap of escape sequences for terminal multiplexers.
+type Wrap int
+
+const (
+	// Sequences are sent to the terminal as is.
+	NoWrap Wrap = iota
+	// Sequences are wrapped for tmux passthrough (allow-passthrough option must be on).
+	Tmux
+	// Sequences are wrapped in DCS for GNU screen.
+	Screen
+)
+
+var wrapNames = [...]string{
+	NoWrap: "none",
+	Tmux:   "tmux",
+	Screen: "screen",
+}
+
+// String implements fmt.Stringer.
+func (w Wrap) String() string {
+	if w < 0 || int(w) >= len(wrapNames) {
+		return fmt.Sprintf("Wrap(%d)", w)
+	}
+	return wrapNames[w]
+}
+
+// Set implements flag.Value.
+func (w *Wrap) Set(val string) error {
+	for i, name := range wrapNames {
+		if name == val {
+			*w = Wrap(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown wrap %q: supported values: none tmux screen", val)
+}
+
+var _ flag.Value = (*Wrap)(nil)
+
+// Options of the output.
+type Options struct {
+	// Write shell script with printf commands instead of raw bytes.
+	Script bool
+	Wrap   Wrap
+}
+
+// Write writes all colors of the table: indexed with OSC 4,
+// foreground with OSC 10, background with OSC 11 and cursor (same as foreground) with OSC 12.
+func Write(w io.Writer, cs termcolor.Table, opts Options) error {
+	seqs := sequences(cs, nil)
+	if fg := cs.Foreground(); !fg.Nil() {
+		seqs = append(seqs, "10;"+spec(fg), "12;"+spec(fg))
+	}
+	if bg := cs.Background(); !bg.Nil() {
+		seqs = append(seqs, "11;"+spec(bg))
+	}
+	return write(w, seqs, opts)
+}
+
+// WriteColors writes indexed colors of the table with OSC 4.
+// It is the fallback for file types, which can't store all colors.
+func WriteColors(w io.Writer, cs termcolor.Table, indexes []int, opts Options) error {
+	return write(w, sequences(cs, indexes), opts)
+}
+
+// sequences returns OSC 4 bodies of the indexed colors (all of them if indexes is nil).
+func sequences(cs termcolor.Table, indexes []int) []string {
+	if indexes == nil {
+		for n := range 256 {
+			indexes = append(indexes, n)
+		}
+	}
+	var seqs []string
+	for _, n := range indexes {
+		if c := cs.Color(n); !c.Nil() {
+			seqs = append(seqs, fmt.Sprintf("4;%d;%s", n, spec(c)))
+		}
+	}
+	return seqs
+}
+
+// spec returns color in XParseColor format.
+func spec(c termcolor.Color) string {
+	r, g, b := c.RGB()
+	return fmt.Sprintf("rgb:%02x/%02x/%02x", r, g, b)
+}
+
+const (
+	esc = "\033"
+	st  = esc + "\\"
+	bel = "\007"
+)
+
+// encode returns escape sequence of the OSC body.
+func encode(body string, wrap Wrap) string {
+	switch wrap {
+	case Tmux:
+		// Escape characters of the wrapped sequence are doubled.
+		return esc + "Ptmux;" + esc + esc + "]" + body + esc + st + st
+	case Screen:
+		// ST would terminate DCS, so wrapped sequence is terminated by BEL.
+		return esc + "P" + esc + "]" + body + bel + st
+	}
+	return esc + "]" + body + st
+}
+
+func write(w io.Writer, seqs []string, opts Options) error {
+	s := &strings.Builder{}
+	if opts.Script {
+		s.WriteString("#!/bin/sh\n")
+	}
+	for _, body := range seqs {
+		seq := encode(body, opts.Wrap)
+		if opts.Script {
+			seq = strings.NewReplacer("\\", "\\\\", esc, "\\033", bel, "\\007").Replace(seq)
+			seq = "printf '" + seq + "'\n"
+		}
+		s.WriteString(seq)
+	}
+	_, err := io.WriteString(w, s.String())
+	return err
+}
@@ -0,0 +1,45 @@
+package osc
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	for _, wrap := range []Wrap{NoWrap, Tmux, Screen} {
+		t.Run(wrap.String(), func(t *testing.T) {
+			body := "4;1;rgb:a5/42/42"
+			script := &strings.Builder{}
+			if err := write(script, []string{body}, Options{Script: true, Wrap: wrap}); err != nil {
+				t.Fatal("write():", err)
+			}
+			raw := &strings.Builder{}
+			if err := write(raw, []string{body}, Options{Wrap: wrap}); err != nil {
+				t.Fatal("write():", err)
+			}
+			out, err := exec.Command("sh", "-c", script.String()).Output()
+			if err != nil {
+				t.Skip("sh is not available:", err)
+			}
+			if string(out) != raw.String() {
+				t.Errorf("script output = %q, want %q", out, raw.String())
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	for _, tt := range []struct {
+		wrap Wrap
+		want string
+	}{
+		{wrap: NoWrap, want: "\033]11;rgb:00/00/00\033\\"},
+		{wrap: Tmux, want: "\033Ptmux;\033\033]11;rgb:00/00/00\033\033\\\033\\"},
+		{wrap: Screen, want: "\033P\033]11;rgb:00/00/00\007\033\\"},
+	} {
+		if got := encode("11;rgb:00/00/00", tt.wrap); got != tt.want {
+			t.Errorf("encode() with %s = %q, want %q", tt.wrap, got, tt.want)
+		}
+	}
+}
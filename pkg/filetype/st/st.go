@@ -0,0 +1,364 @@
+package st
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("st", &fileType{})
+}
+
+type fileType struct{}
+
+var (
+	arrayStart = regexp.MustCompile(`\bcolorname\s*\[\s*\]\s*=\s*\{`)
+	defaultFg  = regexp.MustCompile(`\bdefaultfg\s*=\s*(\d+)\s*;`)
+	defaultBg  = regexp.MustCompile(`\bdefaultbg\s*=\s*(\d+)\s*;`)
+)
+
+// X11 colors, used by default st config.
+var x11Colors = map[string]string{
+	"black":    "#000000",
+	"red3":     "#cd0000",
+	"green3":   "#00cd00",
+	"yellow3":  "#cdcd00",
+	"blue2":    "#0000ee",
+	"magenta3": "#cd00cd",
+	"cyan3":    "#00cdcd",
+	"gray90":   "#e5e5e5",
+	"gray50":   "#7f7f7f",
+	"red":      "#ff0000",
+	"green":    "#00ff00",
+	"yellow":   "#ffff00",
+	"blue":     "#0000ff",
+	"magenta":  "#ff00ff",
+	"cyan":     "#00ffff",
+	"white":    "#ffffff",
+}
+
+// Parse implements ftypes.FileType.
+// Input is st config.h with colorname[] array.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	loc := arrayStart.FindIndex(src)
+	if loc == nil {
+		return nil, errors.New("colorname[] array not found")
+	}
+	cs := &colorScheme{src: src, start: loc[1], fg: -1, bg: -1}
+	entries, end, err := parseArray(src, cs.start)
+	if err != nil {
+		return nil, fmt.Errorf("colorname[]: %v", err)
+	}
+	cs.end = end
+	for _, re := range []*regexp.Regexp{defaultFg, defaultBg} {
+		m := re.FindSubmatch(src)
+		if m == nil {
+			continue
+		}
+		n, _ := strconv.Atoi(string(m[1]))
+		if re == defaultFg {
+			cs.fg = n
+		} else {
+			cs.bg = n
+		}
+	}
+	var last int
+	for _, e := range entries {
+		if e.index >= 256 {
+			cs.extra = append(cs.extra, e)
+			continue
+		}
+		last = e.end
+		if e.value == "" {
+			continue
+		}
+		c, err := parseColor(e.value)
+		if err != nil {
+			return nil, fmt.Errorf("colorname[%d]: %v", e.index, err)
+		}
+		cs.indexed[e.index] = c
+		cs.values[e.index] = e.value
+	}
+	if last == 0 {
+		return nil, errors.New("colorname[] has no colors")
+	}
+	// Extra colors with the rest of array are kept as is,
+	// starting from the line next to the last palette color.
+	if nl := bytes.IndexByte(src[last:end], '\n'); nl >= 0 {
+		cs.tail = last + nl + 1
+	} else {
+		cs.tail = end
+	}
+	for _, e := range cs.extra {
+		if e.value == "" {
+			continue
+		}
+		c, err := parseColor(e.value)
+		switch {
+		case e.index == cs.fg && err != nil, e.index == cs.bg && err != nil:
+			return nil, fmt.Errorf("colorname[%d]: %v", e.index, err)
+		case e.index == cs.fg:
+			cs.foreground = c
+		case e.index == cs.bg:
+			cs.background = c
+		}
+	}
+	if cs.fg >= 0 && cs.fg < 256 {
+		cs.foreground = cs.indexed[cs.fg]
+	}
+	if cs.bg >= 0 && cs.bg < 256 {
+		cs.background = cs.indexed[cs.bg]
+	}
+	return cs, nil
+}
+
+func parseColor(s string) (termcolor.Color, error) {
+	if hex, ok := x11Colors[strings.ToLower(s)]; ok {
+		s = hex
+	}
+	if !termcolor.HEX.MatchString(s) {
+		return termcolor.Color{}, fmt.Errorf("unsupported color %q", s)
+	}
+	return termcolor.FromHEX(s), nil
+}
+
+// Entry of the colorname[] array.
+type entry struct {
+	index      int
+	value      string // Empty for NULL entries.
+	start, end int    // Offsets of the value in source.
+}
+
+// parseArray parses C array initializer from the offset next to «{»
+// and returns its entries and offset of the closing «}».
+func parseArray(src []byte, pos int) ([]entry, int, error) {
+	var entries []entry
+	var index int
+	for pos < len(src) {
+		switch c := src[pos]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			pos++
+		case bytes.HasPrefix(src[pos:], []byte("/*")):
+			end := bytes.Index(src[pos+2:], []byte("*/"))
+			if end < 0 {
+				return nil, 0, errors.New("unterminated comment")
+			}
+			pos += end + 4
+		case bytes.HasPrefix(src[pos:], []byte("//")):
+			end := bytes.IndexByte(src[pos:], '\n')
+			if end < 0 {
+				return nil, 0, errors.New("unterminated array")
+			}
+			pos += end
+		case c == '}':
+			return entries, pos, nil
+		case c == '[':
+			end := bytes.IndexByte(src[pos:], ']')
+			if end < 0 {
+				return nil, 0, errors.New("unterminated designator")
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(string(src[pos+1 : pos+end])))
+			if err != nil || n < 0 {
+				return nil, 0, fmt.Errorf("unsupported designator %s", src[pos:pos+end+1])
+			}
+			index = n
+			pos += end + 1
+			for pos < len(src) && (src[pos] == ' ' || src[pos] == '\t' || src[pos] == '=') {
+				pos++
+			}
+		case c == '"':
+			end := bytes.IndexByte(src[pos+1:], '"')
+			if end < 0 {
+				return nil, 0, errors.New("unterminated string")
+			}
+			entries = append(entries, entry{index: index, value: string(src[pos+1 : pos+1+end]), start: pos, end: pos + end + 2})
+			index++
+			pos += end + 2
+		case bytes.HasPrefix(src[pos:], []byte("0")), bytes.HasPrefix(src[pos:], []byte("NULL")):
+			end := pos + 1
+			if c == 'N' {
+				end = pos + 4
+			}
+			entries = append(entries, entry{index: index, start: pos, end: end})
+			index++
+			pos = end
+		default:
+			return nil, 0, fmt.Errorf("unexpected %q", c)
+		}
+	}
+	return nil, 0, errors.New("unterminated array")
+}
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if arrayStart.Match(head) {
+		return 100
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	return ext == ".h" && strings.HasPrefix(filepath.Base(name), "config")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	table, err := f.Parse(strings.NewReader(template))
+	if err != nil {
+		return nil, err
+	}
+	cs := table.(*colorScheme)
+	cs.background, cs.foreground = src.Background(), src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// template of the encoded config.h.
+const template = `static const char *colorname[] = {
+	[255] = 0,
+
+	/* more colors can be added after 255 to use with DefaultXX */
+	"#ffffff", /* default foreground colour */
+	"#000000", /* default background colour */
+};
+
+unsigned int defaultfg = 256;
+unsigned int defaultbg = 257;
+`
+
+type colorScheme struct {
+	src        []byte
+	start, end int         // Offsets of the colorname[] array body.
+	tail       int         // Offset of extra colors.
+	values     [256]string // Source values of palette colors.
+	extra      []entry
+	fg, bg     int // Indexes of default colors.
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+// Comments of palette parts.
+var sections = map[int]string{
+	0:   "8 normal colors",
+	8:   "8 bright colors",
+	16:  "6x6x6 color cube",
+	232: "24 grayscale colors",
+}
+
+// Write implements termcolor.Table.
+// Body of colorname[] array is replaced with 256 colors.
+// Extra colors after them and the rest of source are kept as is.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	body := &strings.Builder{}
+	body.WriteByte('\n')
+	last := -1
+	for n, c := range cs.indexed {
+		if !c.Nil() {
+			last = n
+		}
+	}
+	for n, c := range cs.indexed[:last+1] {
+		if s, ok := sections[n]; ok {
+			if n > 0 {
+				body.WriteByte('\n')
+			}
+			body.WriteString("\t/* " + s + " */\n")
+		}
+		if c.Nil() {
+			body.WriteString("\t0,\n")
+			continue
+		}
+		val := c.HEX()
+		// Keep X11 color names of unchanged colors.
+		if old, err := parseColor(cs.values[n]); err == nil && old.HEX() == val {
+			val = cs.values[n]
+		}
+		body.WriteString("\t\"" + val + "\",\n")
+	}
+	if last < 255 {
+		body.WriteString("\n\t[255] = 0,\n")
+	}
+	tail := cs.src[cs.tail:cs.end]
+	// Default colors may be changed by generation.
+	var patched []byte
+	pos := cs.tail
+	for _, e := range cs.extra {
+		var c termcolor.Color
+		switch e.index {
+		case cs.fg:
+			c = cs.foreground
+		case cs.bg:
+			c = cs.background
+		default:
+			continue
+		}
+		if c.Nil() || e.value == "" || e.start < pos {
+			continue
+		}
+		if old, err := parseColor(e.value); err == nil && old.HEX() == c.HEX() {
+			continue
+		}
+		patched = append(patched, cs.src[pos:e.start]...)
+		patched = append(patched, '"')
+		patched = append(patched, c.HEX()...)
+		patched = append(patched, '"')
+		pos = e.end
+	}
+	if patched != nil {
+		tail = append(patched, cs.src[pos:cs.end]...)
+	}
+	for _, part := range [][]byte{cs.src[:cs.start], []byte(body.String()), tail, cs.src[cs.end:]} {
+		if _, err := w.Write(part); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
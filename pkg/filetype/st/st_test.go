@@ -0,0 +1,75 @@
+package st
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	src, err := os.ReadFile("testdata/config.h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := new(fileType).Parse(strings.NewReader(string(src)))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#000000"},
+		{name: "foreground", color: cs.Foreground(), hex: "#e5e5e5"},
+		{name: "color 1", color: cs.Color(1), hex: "#cd0000"},
+		{name: "color 12", color: cs.Color(12), hex: "#5c5cff"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	// Source outside of the palette colors is kept byte-for-byte.
+	head, _, _ := strings.Cut(string(src), "\t/* 8 normal colors */")
+	_, tail, _ := strings.Cut(string(src), "[255] = 0,\n")
+	if !strings.HasPrefix(out.String(), head) {
+		t.Error("source before colorname[] is changed")
+	}
+	if !strings.HasSuffix(out.String(), tail) {
+		t.Error("source after palette colors is changed")
+	}
+	got, err := new(fileType).Parse(strings.NewReader(out.String()))
+	if err != nil {
+		t.Fatal("written file is invalid:", err)
+	}
+	for n := range 256 {
+		if got.Color(n).HEX() != cs.Color(n).HEX() {
+			t.Errorf("written color %d = %s, want %s", n, got.Color(n).HEX(), cs.Color(n).HEX())
+		}
+	}
+	if got.Foreground().HEX() != cs.Foreground().HEX() {
+		t.Errorf("written foreground = %s, want %s", got.Foreground().HEX(), cs.Foreground().HEX())
+	}
+}
+
+func TestParseInvalidDesignator(t *testing.T) {
+	for _, src := range []string{
+		"static const char *colorname[] = {\n\t[-1] = \"#ffffff\",\n};\n",
+		"static const char *colorname[] = {\n\t[RED] = \"#ff0000\",\n};\n",
+	} {
+		if _, err := new(fileType).Parse(strings.NewReader(src)); err == nil {
+			t.Errorf("Parse(%q) succeeded", src)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package wezterm
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2/unstable"
+
+	"github.com/shagohead/cterm256/pkg/filetype/internal/tomlpatch"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// Array of strings, like colors.ansi.
+type array struct {
+	elems   []unstable.Range
+	opening int // Offset after «[».
+}
+
+// document is the layout of the source TOML with arrays of strings.
+type document struct {
+	*tomlpatch.Document
+
+	// Arrays of strings by full dotted key.
+	arrays map[string]*array
+}
+
+func parseDocument(src []byte) (*document, error) {
+	doc := &document{
+		Document: tomlpatch.New(src, "colors", '"'),
+		arrays:   make(map[string]*array),
+	}
+	err := doc.Parse(func(header []string, expr *unstable.Node) error {
+		if expr.Kind != unstable.KeyValue || expr.Value().Kind != unstable.Array {
+			return nil
+		}
+		path := strings.Join(append(slices.Clone(header), tomlpatch.Keys(expr.Key())...), ".")
+		return doc.array(path, tomlpatch.KeysEnd(expr.Key()), expr.Value())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// array records ranges of the string array elements. Array node has no raw range,
+// so its opening is found after the key.
+func (doc *document) array(path string, keyEnd int, node *unstable.Node) error {
+	arr := &array{}
+	elems := node.Children()
+	for elems.Next() {
+		elem := elems.Node()
+		if elem.Kind != unstable.String {
+			return nil
+		}
+		arr.elems = append(arr.elems, elem.Raw)
+		doc.Quote = doc.Src[elem.Raw.Offset]
+	}
+	open := bytes.IndexByte(doc.Src[keyEnd:], '[')
+	if open < 0 {
+		return fmt.Errorf("%s: array start not found", path)
+	}
+	arr.opening = keyEnd + open + 1
+	doc.arrays[path] = arr
+	return nil
+}
+
+// patch returns source with colors of the scheme.
+func (doc *document) patch(cs *colorScheme) ([]byte, error) {
+	p := doc.Patch()
+	p.Set("colors", "background", cs.background)
+	p.Set("colors", "foreground", cs.foreground)
+	for _, key := range []string{"ansi", "brights"} {
+		offset := 0
+		if key == "brights" {
+			offset = 8
+		}
+		var palette []termcolor.Color
+		for _, c := range cs.indexed[offset : offset+8] {
+			if c.Nil() {
+				break
+			}
+			palette = append(palette, c)
+		}
+		if len(palette) == 0 {
+			continue
+		}
+		arr, ok := doc.arrays["colors."+key]
+		if !ok {
+			hexes := make([]string, len(palette))
+			for i, c := range palette {
+				hexes[i] = doc.Quoted(c.HEX())
+			}
+			p.Add("colors", key+" = ["+strings.Join(hexes, ", ")+"]")
+			continue
+		}
+		p.Edit(doc.patchArray(arr, palette)...)
+	}
+	for n := 16; n < 256; n++ {
+		p.Set("colors.indexed", strconv.Itoa(n), cs.indexed[n])
+	}
+	return p.Bytes()
+}
+
+// patchArray returns edits of the array elements and appends the missing ones.
+func (doc *document) patchArray(arr *array, palette []termcolor.Color) []tomlpatch.Edit {
+	var edits []tomlpatch.Edit
+	for i, c := range palette {
+		if i < len(arr.elems) {
+			edits = append(edits, doc.Replace(arr.elems[i], c)...)
+		}
+	}
+	if len(palette) <= len(arr.elems) {
+		return edits
+	}
+	text := &strings.Builder{}
+	pos := arr.opening
+	if n := len(arr.elems); n > 0 {
+		last := arr.elems[n-1]
+		pos = int(last.Offset + last.Length)
+	}
+	for i, c := range palette[len(arr.elems):] {
+		if i > 0 || len(arr.elems) > 0 {
+			text.WriteString(", ")
+		}
+		text.WriteString(doc.Quoted(c.HEX()))
+	}
+	return append(edits, tomlpatch.Edit{Start: pos, End: pos, Text: text.String()})
+}
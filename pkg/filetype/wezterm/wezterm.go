@@ -0,0 +1,196 @@
+package wezterm
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("wezterm", &fileType{})
+}
+
+type fileType struct{}
+
+// Parse implements ftypes.FileType.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	var config map[string]any
+	if err := toml.Unmarshal(src, &config); err != nil {
+		return nil, err
+	}
+	cs := &colorScheme{src: src}
+	colorsv, ok := config["colors"]
+	if !ok {
+		return nil, errors.New(`missing "colors" key`)
+	}
+	colors, ok := colorsv.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf(`colors: unexpected type %T`, colorsv)
+	}
+	if err := cs.parsePalette(colors, "ansi", 0); err != nil {
+		return nil, err
+	}
+	if err := cs.parsePalette(colors, "brights", 8); err != nil {
+		return nil, err
+	}
+	if err := cs.parseIndexed(colors); err != nil {
+		return nil, err
+	}
+	if err := parseColor(colors, "background", &cs.background); err != nil {
+		return nil, err
+	}
+	if err := parseColor(colors, "foreground", &cs.foreground); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+var paletteKey = regexp.MustCompile(`(?m)^\s*(ansi|brights)\s*=\s*\[`)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if paletteKey.Match(head) {
+		return 90
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+// Alacritty configs are TOML files too, so the content decides between them.
+func (f *fileType) Support(name string, ext string) bool {
+	return ext == ".toml"
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := &colorScheme{background: src.Background(), foreground: src.Foreground()}
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+type colorScheme struct {
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+	src        []byte
+}
+
+func parseColor(src map[string]any, key string, dst *termcolor.Color) error {
+	val, ok := src[key]
+	if !ok {
+		return nil
+	}
+	col, ok := val.(string)
+	if !ok {
+		return fmt.Errorf("colors.%s: unexpected type %T", key, val)
+	}
+	if !termcolor.HEX.MatchString(col) {
+		return fmt.Errorf("colors.%s: unsupported color value %q", key, col)
+	}
+	*dst = termcolor.FromHEX(col)
+	return nil
+}
+
+func (cs *colorScheme) parsePalette(src map[string]any, key string, offset int) error {
+	val, ok := src[key]
+	if !ok {
+		return nil
+	}
+	palette, ok := val.([]any)
+	if !ok {
+		return fmt.Errorf("colors.%s: unexpected type %T", key, val)
+	}
+	if len(palette) > 8 {
+		return fmt.Errorf("colors.%s: expected 8 colors, got %d", key, len(palette))
+	}
+	for i, v := range palette {
+		col, ok := v.(string)
+		if !ok || !termcolor.HEX.MatchString(col) {
+			return fmt.Errorf("colors.%s[%d]: unsupported color value %v", key, i, v)
+		}
+		cs.indexed[i+offset] = termcolor.FromHEX(col)
+	}
+	return nil
+}
+
+func (cs *colorScheme) parseIndexed(src map[string]any) error {
+	val, ok := src["indexed"]
+	if !ok {
+		return nil
+	}
+	indexed, ok := val.(map[string]any)
+	if !ok {
+		return fmt.Errorf("colors.indexed: unexpected type %T", val)
+	}
+	for key, v := range indexed {
+		n, err := strconv.Atoi(key)
+		if err != nil || n < 16 || n > 255 {
+			return fmt.Errorf("colors.indexed: invalid color number %q", key)
+		}
+		col, ok := v.(string)
+		if !ok || !termcolor.HEX.MatchString(col) {
+			return fmt.Errorf("colors.indexed.%s: unsupported color value %v", key, v)
+		}
+		cs.indexed[n] = termcolor.FromHEX(col)
+	}
+	return nil
+}
+
+// Write implements termcolor.Table.
+// Only colors are changed in the source, the rest of it is kept as is.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	doc, err := parseDocument(cs.src)
+	if err != nil {
+		return err
+	}
+	out, err := doc.patch(cs)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
@@ -0,0 +1,160 @@
+package wezterm
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	in, err := os.Open("testdata/wezterm.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		in.Close()
+	})
+	src, err := io.ReadAll(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cs, err := new(fileType).Parse(strings.NewReader(string(src)))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	unchanged := &strings.Builder{}
+	if err := cs.Write(unchanged); err != nil {
+		t.Fatal("Write():", err)
+	}
+	if unchanged.String() != string(src) {
+		t.Errorf("Write() without changes =\n%s\nwant source", unchanged)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#1a1b26"},
+		{name: "foreground", color: cs.Foreground(), hex: "#c0caf5"},
+		{name: "color 1", color: cs.Color(1), hex: "#f7768e"},
+		{name: "color 8", color: cs.Color(8), hex: "#414868"},
+		{name: "color 17", color: cs.Color(17), hex: "#db4b4b"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	var got struct {
+		Colors struct {
+			Ansi     []string          `toml:"ansi"`
+			Indexed  map[string]string `toml:"indexed"`
+			CursorBG string            `toml:"cursor_bg"`
+		} `toml:"colors"`
+		Metadata struct {
+			Name string `toml:"name"`
+		} `toml:"metadata"`
+	}
+	if err := toml.Unmarshal([]byte(out.String()), &got); err != nil {
+		t.Fatal("written file is invalid:", err)
+	}
+	if n := len(got.Colors.Indexed); n != 240 {
+		t.Errorf("written %d indexed colors, want 240", n)
+	}
+	if got.Colors.Indexed["231"] != cs.Color(231).HEX() {
+		t.Errorf("indexed 231 = %s, want %s", got.Colors.Indexed["231"], cs.Color(231).HEX())
+	}
+	for _, s := range []string{"# Tokyo Night colors for WezTerm\n", "selection_fg = \"#c0caf5\"  # text\n", "[colors.indexed] # extra colors\n16 = "} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("output has no %q", s)
+		}
+	}
+	if len(got.Colors.Ansi) != 8 || got.Colors.CursorBG != "#c0caf5" || got.Metadata.Name != "Tokyo Night" {
+		t.Errorf("other keys are not kept: %+v", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		set  func(cs *colorScheme)
+		want string
+	}{
+		{
+			name: "short palette",
+			src: `[colors]
+ansi = ['#000000', '#ff0000'] # two
+`,
+			set: func(cs *colorScheme) {
+				cs.indexed[1] = termcolor.FromHEX("#aa0000")
+				cs.indexed[2] = termcolor.FromHEX("#00ff00")
+			},
+			want: `[colors]
+ansi = ['#000000', '#aa0000', '#00ff00'] # two
+`,
+		},
+		{
+			name: "missing keys and tables",
+			src: `[colors]
+background = "#000000" # bg
+
+[metadata]
+name = "Test"
+`,
+			set: func(cs *colorScheme) {
+				cs.foreground = termcolor.FromHEX("#ffffff")
+				cs.indexed[0] = termcolor.FromHEX("#111111")
+				cs.indexed[16] = termcolor.FromHEX("#222222")
+			},
+			want: `[colors]
+background = "#000000" # bg
+foreground = "#ffffff"
+ansi = ["#111111"]
+
+[metadata]
+name = "Test"
+
+[colors.indexed]
+16 = "#222222"
+`,
+		},
+		{
+			name: "CRLF line endings",
+			src:  "[colors]\r\nbackground = \"#000000\"\r\n",
+			set: func(cs *colorScheme) {
+				cs.foreground = termcolor.FromHEX("#ffffff")
+				cs.indexed[16] = termcolor.FromHEX("#222222")
+			},
+			want: "[colors]\r\nbackground = \"#000000\"\r\nforeground = \"#ffffff\"\r\n\r\n[colors.indexed]\r\n16 = \"#222222\"\r\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := new(fileType).Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal("Parse():", err)
+			}
+			cs := table.(*colorScheme)
+			tt.set(cs)
+			out := &strings.Builder{}
+			if err := cs.Write(out); err != nil {
+				t.Fatal("Write():", err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Errorf("Write() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
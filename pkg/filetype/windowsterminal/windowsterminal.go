@@ -0,0 +1,530 @@
+package windowsterminal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("windowsterminal", &fileType{})
+}
+
+type fileType struct{}
+
+// Names of ANSI colors 0-15.
+var ansiColors = [16]string{
+	"black", "red", "green", "yellow", "blue", "purple", "cyan", "white",
+	"brightBlack", "brightRed", "brightGreen", "brightYellow",
+	"brightBlue", "brightPurple", "brightCyan", "brightWhite",
+}
+
+// Names of other colors, in order of writing.
+var namedColors = []string{
+	"background",
+	"foreground",
+	"cursorColor",
+	"selectionBackground",
+}
+
+// Parse implements ftypes.FileType.
+// Input is either standalone scheme object or whole settings.json,
+// in which case scheme of the default profile (or the first one) is used.
+// Legacy settings with array of profiles are supported too.
+// JSONC comments and trailing commas are allowed.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	src, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+	data := stripJSONC(src)
+	var settings struct {
+		Schemes        []json.RawMessage `json:"schemes"`
+		Profiles       json.RawMessage   `json:"profiles"`
+		DefaultProfile string            `json:"defaultProfile"`
+		Globals        struct {
+			DefaultProfile string `json:"defaultProfile"`
+		} `json:"globals"`
+	}
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, err
+	}
+	var path []any
+	if settings.Schemes != nil {
+		defaultProfile := settings.DefaultProfile
+		if defaultProfile == "" {
+			defaultProfile = settings.Globals.DefaultProfile
+		}
+		def, err := defaultScheme(settings.Profiles, defaultProfile)
+		if err != nil {
+			return nil, fmt.Errorf("profiles: %v", err)
+		}
+		n, err := selectScheme(settings.Schemes, def)
+		if err != nil {
+			return nil, err
+		}
+		path = []any{"schemes", n}
+	}
+	cs := &colorScheme{
+		src:    src,
+		data:   data,
+		named:  make(map[string]termcolor.Color),
+		values: make(map[string]value),
+	}
+	if err := cs.locate(data, path); err != nil {
+		return nil, err
+	}
+	for key, val := range cs.values {
+		n := slices.Index(ansiColors[:], key)
+		if n < 0 && !slices.Contains(namedColors, key) {
+			continue
+		}
+		c, err := parseColor(val.text)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", key, err)
+		}
+		if n < 0 {
+			cs.named[key] = c
+			continue
+		}
+		cs.indexed[n] = c
+		if n == 0 {
+			cs.upper = val.text == strings.ToUpper(val.text)
+		}
+	}
+	return cs, nil
+}
+
+func parseColor(s string) (termcolor.Color, error) {
+	if len(s) == 4 && s[0] == '#' {
+		s = string([]byte{'#', s[1], s[1], s[2], s[2], s[3], s[3]})
+	}
+	if !termcolor.HEX.MatchString(s) {
+		return termcolor.Color{}, fmt.Errorf("unsupported color %q", s)
+	}
+	return termcolor.FromHEX(s), nil
+}
+
+// defaultScheme returns colorScheme of the profiles defaults. In legacy settings
+// profiles are an array without defaults, so colorScheme of the default profile is used.
+func defaultScheme(profiles json.RawMessage, defaultProfile string) (json.RawMessage, error) {
+	type profile struct {
+		GUID        string          `json:"guid"`
+		ColorScheme json.RawMessage `json:"colorScheme"`
+	}
+	if trimmed := bytes.TrimSpace(profiles); len(trimmed) > 0 && trimmed[0] == '[' {
+		var list []profile
+		if err := json.Unmarshal(profiles, &list); err != nil {
+			return nil, err
+		}
+		for _, p := range list {
+			if p.GUID != "" && p.GUID == defaultProfile {
+				return p.ColorScheme, nil
+			}
+		}
+		return nil, nil
+	}
+	var settings struct {
+		Defaults profile `json:"defaults"`
+	}
+	if len(profiles) > 0 {
+		if err := json.Unmarshal(profiles, &settings); err != nil {
+			return nil, err
+		}
+	}
+	return settings.Defaults.ColorScheme, nil
+}
+
+// selectScheme returns index of scheme, which is set as default colorScheme,
+// or the first one, if default colorScheme isn't set.
+func selectScheme(schemes []json.RawMessage, def json.RawMessage) (int, error) {
+	if len(schemes) == 0 {
+		return 0, errors.New("settings have no schemes")
+	}
+	var name string
+	if json.Unmarshal(def, &name) != nil {
+		// Scheme could be set for dark and light themes separately.
+		var pair struct {
+			Dark string `json:"dark"`
+		}
+		if json.Unmarshal(def, &pair) == nil {
+			name = pair.Dark
+		}
+	}
+	for i, raw := range schemes {
+		var s struct {
+			Name string `json:"name"`
+		}
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return 0, err
+		}
+		if name != "" && s.Name == name {
+			return i, nil
+		}
+	}
+	if name != "" {
+		// Built-in schemes (like Campbell) aren't stored in settings.
+		return 0, fmt.Errorf("default color scheme %q is not found in schemes", name)
+	}
+	return 0, nil
+}
+
+// stripJSONC replaces comments and trailing commas with spaces,
+// so offsets in stripped data are the same as in the source one.
+func stripJSONC(src []byte) []byte {
+	data := bytes.Clone(src)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if data[i] != '\n' && data[i] != '\r' {
+				data[i] = ' '
+			}
+		}
+	}
+	comma := -1
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '"':
+			comma = -1
+			for i++; i < len(data) && data[i] != '"'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+		case c == '/' && bytes.HasPrefix(data[i:], []byte("//")):
+			end := bytes.IndexByte(data[i:], '\n')
+			if end < 0 {
+				end = len(data) - i
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == '/' && bytes.HasPrefix(data[i:], []byte("/*")):
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(data) - i
+			} else {
+				end += 4
+			}
+			blank(i, i+end)
+			i += end - 1
+		case c == ',':
+			comma = i
+		case c == '}' || c == ']':
+			if comma >= 0 {
+				data[comma] = ' '
+			}
+			comma = -1
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+		default:
+			comma = -1
+		}
+	}
+	return data
+}
+
+// String member of the scheme object.
+type value struct {
+	text       string
+	start, end int // Offsets of the quoted value.
+}
+
+// Opened object or array in the JSON tokens stream.
+type frame struct {
+	object    bool
+	expectKey bool
+	key       string
+	index     int
+}
+
+func (f *frame) pos() any {
+	if f.object {
+		return f.key
+	}
+	return f.index
+}
+
+// next moves frame to the next member.
+func (f *frame) next() {
+	if f.object {
+		f.expectKey = true
+	} else {
+		f.index++
+	}
+}
+
+// locate walks through data to the scheme object found by path
+// and collects offsets of its string members.
+func (cs *colorScheme) locate(data []byte, path []any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	var stack []*frame
+	// atTarget reports whether the top frame is the scheme object.
+	atTarget := func() bool {
+		if len(stack) != len(path)+1 || !stack[len(stack)-1].object {
+			return false
+		}
+		for i, p := range path {
+			if stack[i].pos() != p {
+				return false
+			}
+		}
+		return true
+	}
+	found := false
+	// advance moves the top frame after its member was read.
+	advance := func() {
+		if len(stack) == 0 {
+			return
+		}
+		if atTarget() {
+			cs.lastEnd = int(dec.InputOffset())
+		}
+		stack[len(stack)-1].next()
+	}
+	for {
+		// Only whitespace, «:» and «,» are between the previous token and the next one,
+		// since comments are stripped.
+		prev := int(dec.InputOffset())
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if n := len(stack); n > 0 && stack[n-1].expectKey {
+			if key, ok := tok.(string); ok {
+				stack[n-1].key = key
+				stack[n-1].expectKey = false
+				continue
+			}
+		}
+		switch tok := tok.(type) {
+		case json.Delim:
+			switch tok {
+			case '{', '[':
+				stack = append(stack, &frame{object: tok == '{', expectKey: tok == '{'})
+				if atTarget() {
+					found = true
+					cs.lastEnd = int(dec.InputOffset())
+				}
+			case '}', ']':
+				stack = stack[:len(stack)-1]
+				advance()
+			}
+		case string:
+			if atTarget() {
+				end := int(dec.InputOffset())
+				start := prev + bytes.IndexByte(data[prev:end], '"')
+				cs.values[stack[len(stack)-1].key] = value{text: tok, start: start, end: end}
+			}
+			advance()
+		default:
+			advance()
+		}
+	}
+	if !found {
+		return errors.New("color scheme not found")
+	}
+	return nil
+}
+
+var schemeKey = regexp.MustCompile(`"(brightBlack|brightPurple|purple)"\s*:`)
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	if schemeKey.Match(head) {
+		return 90
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	switch ext {
+	case ".json", ".jsonc":
+	default:
+		return false
+	}
+	name = strings.ToLower(name)
+	return strings.Contains(name, "windowsterminal") || strings.Contains(name, "windows-terminal") ||
+		strings.Contains(name, "windows_terminal")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	table, err := f.Parse(strings.NewReader(template))
+	if err != nil {
+		return nil, err
+	}
+	cs := table.(*colorScheme)
+	cs.named["background"] = src.Background()
+	cs.named["foreground"] = src.Foreground()
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+// template of the encoded scheme.
+const template = `{
+    "name": "cterm256"
+}
+`
+
+type colorScheme struct {
+	src     []byte
+	data    []byte // Source without comments.
+	values  map[string]value
+	lastEnd int  // Offset after the last member of the scheme object.
+	upper   bool // Whether source uses upper case HEX.
+	indexed [256]termcolor.Color
+	named   map[string]termcolor.Color
+}
+
+// Write implements termcolor.Table.
+// Colors of the scheme object are patched in place, other content of the source is kept as is.
+// Colors 16-255 can't be stored in scheme, see Unsupported.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	type edit struct {
+		start, end int
+		text       string
+	}
+	var edits []edit
+	var added []string
+	set := func(key string, c termcolor.Color) {
+		if c.Nil() {
+			return
+		}
+		hex := c.HEX()
+		if cs.upper {
+			hex = strings.ToUpper(hex)
+		}
+		val, ok := cs.values[key]
+		if !ok {
+			added = append(added, key+`": "`+hex)
+			return
+		}
+		if old, err := parseColor(val.text); err == nil && old.HEX() == c.HEX() {
+			return
+		}
+		edits = append(edits, edit{start: val.start, end: val.end, text: `"` + hex + `"`})
+	}
+	for n, name := range ansiColors {
+		set(name, cs.indexed[n])
+	}
+	for _, name := range namedColors {
+		set(name, cs.named[name])
+	}
+	if len(added) > 0 {
+		text := &strings.Builder{}
+		indent := cs.indent()
+		lineEnd := bytes.IndexByte(cs.data[cs.lastEnd:], '\n')
+		if lineEnd < 0 || len(cs.values) == 0 || bytes.ContainsAny(cs.data[cs.lastEnd:cs.lastEnd+lineEnd], "}]") {
+			for i, s := range added {
+				if i > 0 || len(cs.values) > 0 {
+					text.WriteByte(',')
+				}
+				text.WriteString("\n" + indent + `"` + s + `"`)
+			}
+			edits = append(edits, edit{start: cs.lastEnd, end: cs.lastEnd, text: text.String()})
+		} else {
+			// Added members are written after comments at the line of the last one.
+			lineEnd += cs.lastEnd
+			if cs.src[lineEnd-1] == '\r' {
+				lineEnd--
+			}
+			trailing := bytes.HasPrefix(bytes.TrimLeft(cs.src[cs.lastEnd:], " \t"), []byte(","))
+			if !trailing {
+				edits = append(edits, edit{start: cs.lastEnd, end: cs.lastEnd, text: ","})
+			}
+			for i, s := range added {
+				text.WriteString("\n" + indent + `"` + s + `"`)
+				if trailing || i < len(added)-1 {
+					text.WriteByte(',')
+				}
+			}
+			edits = append(edits, edit{start: lineEnd, end: lineEnd, text: text.String()})
+		}
+	}
+	slices.SortFunc(edits, func(a, b edit) int {
+		return a.start - b.start
+	})
+	var pos int
+	for _, e := range edits {
+		if _, err := w.Write(cs.src[pos:e.start]); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(e.text); err != nil {
+			return err
+		}
+		pos = e.end
+	}
+	_, err := w.Write(cs.src[pos:])
+	return err
+}
+
+// indent returns indentation of the first string member of the scheme object.
+func (cs *colorScheme) indent() string {
+	first := -1
+	for _, val := range cs.values {
+		if first < 0 || val.start < first {
+			first = val.start
+		}
+	}
+	if first < 0 {
+		return "    "
+	}
+	line := cs.src[bytes.LastIndexByte(cs.src[:first], '\n')+1:]
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+// Unsupported implements filetype.Limited.
+func (cs *colorScheme) Unsupported() []int {
+	var indexes []int
+	for n := 16; n < 256; n++ {
+		if !cs.indexed[n].Nil() {
+			indexes = append(indexes, n)
+		}
+	}
+	return indexes
+}
+
+var _ filetype.Limited = (*colorScheme)(nil)
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.named["background"]
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.named["foreground"]
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
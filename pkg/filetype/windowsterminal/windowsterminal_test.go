@@ -0,0 +1,167 @@
+package windowsterminal
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestParseAndWrite(t *testing.T) {
+	in, err := os.Open("testdata/settings.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		in.Close()
+	})
+	cs, err := new(fileType).Parse(in)
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	for _, want := range []struct {
+		name  string
+		color termcolor.Color
+		hex   string
+	}{
+		{name: "background", color: cs.Background(), hex: "#1d1f21"},
+		{name: "foreground", color: cs.Foreground(), hex: "#c5c8c6"},
+		{name: "color 1", color: cs.Color(1), hex: "#a54242"},
+		{name: "color 7", color: cs.Color(7), hex: "#707880"},
+	} {
+		if got := want.color.HEX(); got != want.hex {
+			t.Errorf("%s.HEX() = %s, want %s", want.name, got, want.hex)
+		}
+	}
+
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	for _, s := range []string{"// Set by hand", "/* Only normal colors are defined */", `"#0C0C0C"`} {
+		if !strings.Contains(out.String(), s) {
+			t.Errorf("written file lost %q", s)
+		}
+	}
+	var got struct {
+		Schemes []map[string]string `json:"schemes"`
+	}
+	if err := json.Unmarshal(stripJSONC([]byte(out.String())), &got); err != nil {
+		t.Fatal("written file is invalid:", err)
+	}
+	scheme := got.Schemes[1]
+	if want := strings.ToUpper(cs.Color(15).HEX()); scheme["brightWhite"] != want {
+		t.Errorf("brightWhite = %q, want %q", scheme["brightWhite"], want)
+	}
+	if _, ok := got.Schemes[0]["brightWhite"]; ok {
+		t.Error("not selected scheme was changed")
+	}
+	if n := len(cs.(*colorScheme).Unsupported()); n != 240 {
+		t.Errorf("Unsupported() returns %d colors, want 240", n)
+	}
+}
+
+func TestParseStandalone(t *testing.T) {
+	cs, err := new(fileType).Parse(strings.NewReader(`{"name": "x", "background": "#000", "foreground": "#ffffff", "black": "#000000"}`))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	if got := cs.Background().HEX(); got != "#000000" {
+		t.Errorf("Background().HEX() = %s, want #000000", got)
+	}
+}
+
+func TestParseMissingDefaultScheme(t *testing.T) {
+	_, err := new(fileType).Parse(strings.NewReader(`{
+	"profiles": {"defaults": {"colorScheme": "Campbell"}},
+	"schemes": [{"name": "One Half Dark", "background": "#282c34"}]
+}`))
+	if err == nil || !strings.Contains(err.Error(), `"Campbell"`) {
+		t.Errorf("Parse() error = %v, want missing Campbell scheme", err)
+	}
+}
+
+func TestWriteIndent(t *testing.T) {
+	const src = "{\n    \"name\": \"x\",\n  \"black\": \"#000000\",\n\t\"red\": \"#ff0000\"\n}\n"
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	cs.SetColor(2, termcolor.FromHEX("#00ff00"))
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	want := "{\n    \"name\": \"x\",\n  \"black\": \"#000000\",\n\t\"red\": \"#ff0000\",\n    \"green\": \"#00FF00\"\n}\n"
+	if got := out.String(); got != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestParseEscapedQuotes(t *testing.T) {
+	const src = `{"name": "\"x\" \\", "black": "#000000"}`
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse() fails:", err)
+	}
+	name := cs.(*colorScheme).values["name"]
+	if got, want := src[name.start:name.end], `"\"x\" \\"`; got != want {
+		t.Errorf("name value = %s, want %s", got, want)
+	}
+	cs.SetColor(0, termcolor.FromHEX("#111111"))
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	if want := `{"name": "\"x\" \\", "black": "#111111"}`; out.String() != want {
+		t.Errorf("Write() = %s, want %s", out, want)
+	}
+}
+
+func TestParseLegacyProfiles(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		src  string
+		want string
+	}{
+		{
+			name: "default profile",
+			src: `{
+	"globals": {"defaultProfile": "{b}"},
+	"profiles": [
+		{"guid": "{a}", "colorScheme": "One"},
+		{"guid": "{b}", "colorScheme": "Two"}
+	],
+	"schemes": [{"name": "One", "black": "#111111"}, {"name": "Two", "black": "#222222"}]
+}`,
+			want: "#222222",
+		},
+		{
+			name: "without default profile",
+			src: `{
+	"profiles": [{"guid": "{a}", "name": "cmd"}],
+	"schemes": [{"name": "One", "black": "#111111"}, {"name": "Two", "black": "#222222"}]
+}`,
+			want: "#111111",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			cs, err := new(fileType).Parse(strings.NewReader(tt.src))
+			if err != nil {
+				t.Fatal("Parse() fails:", err)
+			}
+			if got := cs.Color(0).HEX(); got != tt.want {
+				t.Errorf("Color(0).HEX() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+	if _, err := new(fileType).Parse(strings.NewReader(`{"profiles": 1, "schemes": [{"name": "One"}]}`)); err == nil {
+		t.Error("Parse() of invalid profiles succeeded")
+	}
+}
@@ -0,0 +1,277 @@
+package xresources
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shagohead/cterm256/pkg/filetype"
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func init() {
+	filetype.Register("xresources", &fileType{})
+}
+
+type fileType struct{}
+
+var (
+	// Color resource: prefix (like «*», «*.» or «URxvt*»), name and value.
+	resourceLine = regexp.MustCompile(`^(\s*[\w.*?-]*[.*])(color\d+|foreground|background)(\s*:\s*)(.*?)\s*$`)
+	defineLine   = regexp.MustCompile(`^\s*#\s*define\s+(\w+)\s+(.*?)\s*$`)
+	xcolorSpec   = regexp.MustCompile(`^rgb:([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})/([0-9a-fA-F]{1,4})$`)
+)
+
+// Special indexes of named resources.
+const (
+	background = -1
+	foreground = -2
+)
+
+// Parse implements ftypes.FileType.
+func (f *fileType) Parse(input io.Reader) (termcolor.Table, error) {
+	cs := &colorScheme{}
+	defines := make(map[string]string)
+	var resources []*resource
+	scan := bufio.NewScanner(input)
+	var ln int
+	for scan.Scan() {
+		ln++
+		l := &line{raw: scan.Text()}
+		cs.lines = append(cs.lines, l)
+		if m := defineLine.FindStringSubmatch(l.raw); m != nil {
+			defines[m[1]] = m[2]
+			continue
+		}
+		m := resourceLine.FindStringSubmatch(l.raw)
+		if m == nil {
+			continue
+		}
+		res := &resource{prefix: m[1], name: m[2], sep: m[3], value: m[4]}
+		switch res.name {
+		case "background":
+			res.index = background
+		case "foreground":
+			res.index = foreground
+		default:
+			n, err := strconv.Atoi(res.name[5:])
+			if err != nil || n > 255 {
+				continue
+			}
+			res.index = n
+		}
+		val := res.value
+		if v, ok := defines[val]; ok {
+			val = v
+		}
+		c, err := parseColor(val)
+		if err != nil {
+			return nil, fmt.Errorf("%d line: %s: %v", ln, res.name, err)
+		}
+		res.color = c
+		l.res = res
+		resources = append(resources, res)
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+	// In Xrm application scoped resources (like «URxvt*color1») are more specific
+	// than generic ones, so they override the table for their application only.
+	// Table is made of generic resources, or of the first application ones, if there are
+	// no generic. Resources of other applications are kept as is.
+	hasGeneric := slices.ContainsFunc(resources, func(res *resource) bool { return isGeneric(res.prefix) })
+	for _, res := range resources {
+		if hasGeneric && isGeneric(res.prefix) || !hasGeneric && res.prefix == resources[0].prefix {
+			res.table = true
+			*cs.color(res.index) = res.color
+		}
+	}
+	return cs, nil
+}
+
+// isGeneric reports whether resource prefix is not scoped to application.
+func isGeneric(prefix string) bool {
+	prefix = strings.TrimSpace(prefix)
+	return prefix == "*" || prefix == "*."
+}
+
+// parseColor parses «#rrggbb» or X11 «rgb:r/g/b» color specs.
+func parseColor(val string) (termcolor.Color, error) {
+	if termcolor.HEX.MatchString(val) {
+		return termcolor.FromHEX(val), nil
+	}
+	if m := xcolorSpec.FindStringSubmatch(val); m != nil {
+		hex := "#"
+		for _, ch := range m[1:] {
+			v, _ := strconv.ParseUint(ch, 16, 16)
+			max := uint64(1)<<(4*len(ch)) - 1
+			hex += fmt.Sprintf("%02x", (v*255+max/2)/max)
+		}
+		return termcolor.FromHEX(hex), nil
+	}
+	return termcolor.Color{}, fmt.Errorf("unsupported color value %q", val)
+}
+
+// Detect implements filetype.Detector.
+func (f *fileType) Detect(head []byte) int {
+	for _, line := range bytes.Split(head, []byte("\n")) {
+		if resourceLine.Match(line) {
+			return 90
+		}
+	}
+	return 0
+}
+
+// Support implements ftypes.FileType.
+func (f *fileType) Support(name string, ext string) bool {
+	base := strings.ToLower(path.Base(name))
+	return strings.Contains(base, "xresources") || strings.Contains(base, "xdefaults")
+}
+
+var _ filetype.FileType = (*fileType)(nil)
+
+var _ filetype.Detector = (*fileType)(nil)
+
+// Encode implements filetype.Encoder.
+func (f *fileType) Encode(src termcolor.Table) (termcolor.Table, error) {
+	cs := &colorScheme{background: src.Background(), foreground: src.Foreground()}
+	for n := range cs.indexed {
+		cs.indexed[n] = src.Color(n)
+	}
+	return cs, nil
+}
+
+var _ filetype.Encoder = (*fileType)(nil)
+
+type line struct {
+	raw string
+	res *resource
+}
+
+type resource struct {
+	prefix string
+	name   string
+	sep    string
+	value  string // Source value, hex or macro name.
+	index  int
+	color  termcolor.Color // Parsed value.
+	table  bool            // Resource is a color of the table, not an application override.
+}
+
+type colorScheme struct {
+	lines      []*line
+	indexed    [256]termcolor.Color
+	background termcolor.Color
+	foreground termcolor.Color
+}
+
+func (cs *colorScheme) color(index int) *termcolor.Color {
+	switch index {
+	case background:
+		return &cs.background
+	case foreground:
+		return &cs.foreground
+	}
+	return &cs.indexed[index]
+}
+
+// Write implements termcolor.Table.
+// Values of table resources are updated (macro references are kept if color is not changed)
+// and missing colors are appended after the last color of the table prefix: generic («*» or «*.»)
+// or application scoped one, if there are no generic resources.
+// Resources of other applications are kept as is.
+func (cs *colorScheme) Write(w termcolor.Writer) error {
+	// Colors defined with each table prefix and the last line of it.
+	defined := make(map[string]map[int]bool)
+	last := make(map[string]int)
+	var hasResources bool
+	for i, l := range cs.lines {
+		if l.res == nil {
+			continue
+		}
+		hasResources = true
+		if !l.res.table {
+			continue
+		}
+		if defined[l.res.prefix] == nil {
+			defined[l.res.prefix] = make(map[int]bool)
+		}
+		defined[l.res.prefix][l.res.index] = true
+		last[l.res.prefix] = i
+	}
+	prefixes := make(map[int]string, len(last))
+	for prefix, i := range last {
+		prefixes[i] = prefix
+	}
+
+	s := &strings.Builder{}
+	for i, l := range cs.lines {
+		if l.res == nil {
+			s.WriteString(l.raw)
+		} else {
+			res := l.res
+			value := res.value
+			if c := *cs.color(res.index); res.table && !c.Nil() && c.HEX() != res.color.HEX() {
+				value = c.HEX()
+			}
+			s.WriteString(res.prefix + res.name + res.sep + value)
+		}
+		s.WriteByte('\n')
+		if prefix, ok := prefixes[i]; ok {
+			cs.writeMissing(s, prefix, defined[prefix])
+		}
+	}
+	if !hasResources {
+		cs.writeMissing(s, "*.", nil)
+	}
+	_, err := w.WriteString(s.String())
+	return err
+}
+
+func (cs *colorScheme) writeMissing(s *strings.Builder, prefix string, defined map[int]bool) {
+	for _, n := range []int{background, foreground} {
+		if c := *cs.color(n); !defined[n] && !c.Nil() {
+			name := "background"
+			if n == foreground {
+				name = "foreground"
+			}
+			fmt.Fprintf(s, "%s%s: %s\n", prefix, name, c.HEX())
+		}
+	}
+	for n, c := range cs.indexed {
+		if !defined[n] && !c.Nil() {
+			fmt.Fprintf(s, "%scolor%d: %s\n", prefix, n, c.HEX())
+		}
+	}
+}
+
+// SetColor implements termcolor.Table.
+func (cs *colorScheme) SetColor(number int, color termcolor.Color) {
+	cs.indexed[number] = color
+}
+
+// Color implements termcolor.Table.
+func (cs *colorScheme) Color(number int) termcolor.Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return cs.indexed[number]
+}
+
+// Background implements termcolor.Table.
+func (cs *colorScheme) Background() termcolor.Color {
+	return cs.background
+}
+
+// Foreground implements termcolor.Table.
+func (cs *colorScheme) Foreground() termcolor.Color {
+	return cs.foreground
+}
+
+var _ termcolor.Table = (*colorScheme)(nil)
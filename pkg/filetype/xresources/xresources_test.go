@@ -0,0 +1,125 @@
+package xresources
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+const src = `! Tomorrow Night
+#include "fonts.Xresources"
+#define t_background #1d1f21
+#define t_red #cc6666
+
+*.foreground: #c5c8c6
+*.background: t_background
+*.color0: #1d1f21
+*.color1: t_red
+*.color2: #b5bd68
+*.color3: rgb:f0/c6/74
+*.color4: #81a2be
+*.color5: #b294bb
+*.color6: #8abeb7
+*.color7: #c5c8c6
+URxvt*color1: #ff0000
+URxvt.font: xft:monospace:size=11
+`
+
+func TestParse(t *testing.T) {
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	for _, tt := range []struct {
+		name string
+		got  string
+		want string
+	}{
+		{name: "background", got: cs.Background().HEX(), want: "#1d1f21"},
+		{name: "foreground", got: cs.Foreground().HEX(), want: "#c5c8c6"},
+		{name: "color1", got: cs.Color(1).HEX(), want: "#cc6666"},
+		{name: "color3", got: cs.Color(3).HEX(), want: "#f0c674"},
+	} {
+		if tt.got != tt.want {
+			t.Errorf("%s = %s, want %s", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestParseScoped(t *testing.T) {
+	cs, err := new(fileType).Parse(strings.NewReader("URxvt*color1: #ff0000\nURxvt*background: #000000\n"))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	if got := cs.Color(1).HEX(); got != "#ff0000" {
+		t.Errorf("color1 = %s, want #ff0000", got)
+	}
+	cs.SetColor(1, termcolor.FromHEX("#aa0000"))
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	if want := "URxvt*color1: #aa0000\nURxvt*background: #000000\n"; out.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestParseScopedApplications(t *testing.T) {
+	const src = "URxvt*color1: #ff0000\nXTerm*color1: #00ff00\nXTerm*color2: #0000ff\n"
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	// Table is made of the first application resources.
+	if got := cs.Color(1).HEX(); got != "#ff0000" {
+		t.Errorf("color1 = %s, want #ff0000", got)
+	}
+	if got := cs.Color(2); !got.Nil() {
+		t.Errorf("color2 = %s, want nil", got.HEX())
+	}
+	cs.SetColor(1, termcolor.FromHEX("#aa0000"))
+	cs.SetColor(2, termcolor.FromHEX("#00aa00"))
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	want := "URxvt*color1: #aa0000\nURxvt*color2: #00aa00\nXTerm*color1: #00ff00\nXTerm*color2: #0000ff\n"
+	if out.String() != want {
+		t.Errorf("Write() =\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	cs, err := new(fileType).Parse(strings.NewReader(src))
+	if err != nil {
+		t.Fatal("Parse():", err)
+	}
+	if err := termcolor.Generate(cs, io.Discard); err != nil {
+		t.Fatal("Generate():", err)
+	}
+	cs.SetColor(2, termcolor.FromHEX("#123456"))
+	out := &strings.Builder{}
+	if err := cs.Write(out); err != nil {
+		t.Fatal("Write():", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"! Tomorrow Night\n#include \"fonts.Xresources\"\n#define t_background #1d1f21\n",
+		"*.background: t_background\n",
+		"*.color1: t_red\n",
+		"*.color2: #123456\n",
+		"*.color3: rgb:f0/c6/74\n",
+		"*.color7: #c5c8c6\n*.color8: ",
+		// Application override is more specific than the generic resource.
+		"*.color255: " + cs.Color(255).HEX() + "\nURxvt*color1: #ff0000\nURxvt.font: xft:monospace:size=11\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Write() output missing %q", want)
+		}
+	}
+	if n := strings.Count(got, "color"); n != 257 {
+		t.Errorf("Write() output has %d colors, want 257", n)
+	}
+}
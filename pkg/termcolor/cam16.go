@@ -0,0 +1,170 @@
+package termcolor
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// CAM16 color appearance model with sRGB viewing conditions:
+// D65 white, adapting luminance 64/π/5 cd/m², 20% background and average surround.
+// Uniform color space (CAM16-UCS) by Li et al. (2017).
+var cam16 = newCAM16Conditions(64/math.Pi/5, 20, 1, 0.69, 1)
+
+var (
+	m16 = [3][3]float64{
+		{0.401288, 0.650173, -0.051461},
+		{-0.250268, 1.204414, 0.045854},
+		{-0.002079, 0.048952, 0.953127},
+	}
+	m16inv = invert3(m16)
+)
+
+type cam16Conditions struct {
+	fl, n, z, nbb, nc, c float64
+	drgb                 [3]float64
+	aw                   float64
+}
+
+func newCAM16Conditions(la, yb, f, c, nc float64) *cam16Conditions {
+	wx, wy, wz := 95.047, 100.0, 108.883
+	w := mul3(m16, wx, wy, wz)
+	d := f * (1 - 1/3.6*math.Exp((-la-42)/92))
+	d = math.Max(0, math.Min(1, d))
+	k := 1 / (5*la + 1)
+	k4 := k * k * k * k
+	cs := &cam16Conditions{
+		fl: 0.2*k4*(5*la) + 0.1*(1-k4)*(1-k4)*math.Cbrt(5*la),
+		n:  yb / wy,
+		nc: nc,
+		c:  c,
+	}
+	cs.z = 1.48 + math.Sqrt(cs.n)
+	cs.nbb = 0.725 * math.Pow(cs.n, -0.2)
+	var aw [3]float64
+	for i := range 3 {
+		cs.drgb[i] = d*wy/w[i] + 1 - d
+		aw[i] = cs.adapt(cs.drgb[i] * w[i])
+	}
+	cs.aw = (2*aw[0] + aw[1] + 0.05*aw[2] - 0.305) * cs.nbb
+	return cs
+}
+
+func (cs *cam16Conditions) adapt(v float64) float64 {
+	p := math.Pow(cs.fl*math.Abs(v)/100, 0.42)
+	return math.Copysign(400*p/(p+27.13), v) + 0.1
+}
+
+func (cs *cam16Conditions) unadapt(v float64) float64 {
+	v -= 0.1
+	a := math.Abs(v)
+	return math.Copysign(100/cs.fl*math.Pow(27.13*a/(400-a), 1/0.42), v)
+}
+
+func (cs *cam16Conditions) eccentricity(h float64) float64 {
+	return 0.25 * (math.Cos(h+2) + 3.8)
+}
+
+// ucs converts color into CAM16-UCS J', a', b'.
+func (cs *cam16Conditions) ucs(col colorful.Color) (j, a, b float64) {
+	x, y, z := col.Xyz()
+	rgb := mul3(m16, x*100, y*100, z*100)
+	var ra [3]float64
+	for i := range 3 {
+		ra[i] = cs.adapt(cs.drgb[i] * rgb[i])
+	}
+	ca := ra[0] - 12*ra[1]/11 + ra[2]/11
+	cb := (ra[0] + ra[1] - 2*ra[2]) / 9
+	h := math.Atan2(cb, ca)
+	achr := (2*ra[0] + ra[1] + 0.05*ra[2] - 0.305) * cs.nbb
+	jj := 100 * math.Pow(math.Max(0, achr/cs.aw), cs.c*cs.z)
+	t := 50000.0 / 13 * cs.nc * cs.nbb * cs.eccentricity(h) * math.Hypot(ca, cb) / (ra[0] + ra[1] + 21.0/20*ra[2])
+	chroma := math.Pow(t, 0.9) * math.Sqrt(jj/100) * math.Pow(1.64-math.Pow(0.29, cs.n), 0.73)
+	m := chroma * math.Pow(cs.fl, 0.25)
+	mu := math.Log(1+0.0228*m) / 0.0228
+	return 1.7 * jj / (1 + 0.007*jj), mu * math.Cos(h), mu * math.Sin(h)
+}
+
+// fromUCS converts CAM16-UCS J', a', b' into color.
+func (cs *cam16Conditions) fromUCS(j, a, b float64) colorful.Color {
+	if j <= 0 {
+		return colorful.Color{}
+	}
+	jj := j / (1.7 - 0.007*j)
+	m := (math.Exp(0.0228*math.Hypot(a, b)) - 1) / 0.0228
+	chroma := m / math.Pow(cs.fl, 0.25)
+	h := math.Atan2(b, a)
+	t := math.Pow(chroma/(math.Sqrt(jj/100)*math.Pow(1.64-math.Pow(0.29, cs.n), 0.73)), 1/0.9)
+	achr := cs.aw * math.Pow(jj/100, 1/(cs.c*cs.z))
+	p2 := achr/cs.nbb + 0.305
+	const p3 = 21.0 / 20
+	var ca, cb float64
+	if t > 0 {
+		p1 := 50000.0 / 13 * cs.nc * cs.nbb * cs.eccentricity(h) / t
+		hs, hc := math.Sin(h), math.Cos(h)
+		if math.Abs(hs) >= math.Abs(hc) {
+			p4 := p1 / hs
+			cb = p2 * (2 + p3) * (460.0 / 1403) / (p4 + (2+p3)*(220.0/1403)*(hc/hs) - 27.0/1403 + p3*(6300.0/1403))
+			ca = cb * hc / hs
+		} else {
+			p5 := p1 / hc
+			ca = p2 * (2 + p3) * (460.0 / 1403) / (p5 + (2+p3)*(220.0/1403) - (27.0/1403-p3*(6300.0/1403))*(hs/hc))
+			cb = ca * hs / hc
+		}
+	}
+	ra := [3]float64{
+		(460*p2 + 451*ca + 288*cb) / 1403,
+		(460*p2 - 891*ca - 261*cb) / 1403,
+		(460*p2 - 220*ca - 6300*cb) / 1403,
+	}
+	var rgb [3]float64
+	for i := range 3 {
+		rgb[i] = cs.unadapt(ra[i]) / cs.drgb[i]
+	}
+	xyz := mul3(m16inv, rgb[0], rgb[1], rgb[2])
+	return colorful.Xyz(xyz[0]/100, xyz[1]/100, xyz[2]/100)
+}
+
+func mul3(m [3][3]float64, x, y, z float64) [3]float64 {
+	return [3]float64{
+		m[0][0]*x + m[0][1]*y + m[0][2]*z,
+		m[1][0]*x + m[1][1]*y + m[1][2]*z,
+		m[2][0]*x + m[2][1]*y + m[2][2]*z,
+	}
+}
+
+func invert3(m [3][3]float64) (r [3][3]float64) {
+	det := m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+	for i := range 3 {
+		for j := range 3 {
+			// Cofactor of m[j][i] (transposed) divided by determinant.
+			a, b := (j+1)%3, (j+2)%3
+			c, d := (i+1)%3, (i+2)%3
+			r[i][j] = (m[a][c]*m[b][d] - m[a][d]*m[b][c]) / det
+		}
+	}
+	return
+}
+
+// cam16Scale maps CAM16-UCS coordinates (J' in [0..100]) to lightness range of other spaces.
+const cam16Scale = 100
+
+type cam16Space struct{}
+
+// To implements ColorSpace.
+func (cam16Space) To(c Color) (l, x, y float64) {
+	j, a, b := cam16.ucs(c.src)
+	return j / cam16Scale, a / cam16Scale, b / cam16Scale
+}
+
+// From implements ColorSpace.
+func (cam16Space) From(l, x, y float64) Color {
+	return Color{src: cam16.fromUCS(l*cam16Scale, x*cam16Scale, y*cam16Scale), set: true}
+}
+
+// Blend implements ColorSpace.
+func (cam16Space) Blend(x1, y1, x2, y2, scale float64) (x, y float64) {
+	return blend(x1, x2, scale), blend(y1, y2, scale)
+}
@@ -3,6 +3,7 @@ package termcolor
 import (
 	"fmt"
 	"regexp"
+	"strings"
 
 	"github.com/lucasb-eyer/go-colorful"
 )
@@ -16,6 +17,9 @@ func init() {
 type Color struct {
 	src colorful.Color
 	set bool
+
+	// Distance (CIE76) of move into sRGB gamut while generation.
+	moved float64
 }
 
 func (h Color) Nil() bool {
@@ -24,7 +28,12 @@ func (h Color) Nil() bool {
 
 func (h Color) String() string {
 	l, a, b := h.src.Lab()
-	return fmt.Sprintf("%s Lab: %0.2f %0.2f %0.2f", h.HEX(), l, a, b)
+	hh, hs, hl := h.src.HSLuv()
+	s := fmt.Sprintf("%s Lab: %0.2f %0.2f %0.2f HSLuv: %0.1f %0.2f %0.2f", h.HEX(), l, a, b, hh, hs, hl)
+	if h.moved > 0 {
+		s += fmt.Sprintf(" gamut moved: %0.3f", h.moved)
+	}
+	return s
 }
 
 func (h Color) HEX() string {
@@ -40,14 +49,44 @@ func (h Color) Lightness() float64 {
 	return l
 }
 
+// Luminance returns relative luminance (Y of CIE XYZ) in [0..1] range.
+func (h Color) Luminance() float64 {
+	_, y, _ := h.src.Clamped().Xyz()
+	return y
+}
+
 func FromHEX(hex string) Color {
+	if !strings.HasPrefix(hex, "#") {
+		hex = "#" + hex
+	}
 	c, err := colorful.Hex(hex)
 	if err != nil {
 		panic(fmt.Sprintf("parsing %s: %v", hex, err))
 	}
-	return Color{c, true}
+	return Color{src: c, set: true}
 }
 
 func color(l, a, b float64) Color {
-	return Color{colorful.Lab(l, a, b), true}
+	return Color{src: colorful.Lab(l, a, b), set: true}
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (h Color) MarshalText() ([]byte, error) {
+	if h.Nil() {
+		return nil, nil
+	}
+	return []byte(h.HEX()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (h *Color) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*h = Color{}
+		return nil
+	}
+	if !HEX.Match(text) {
+		return fmt.Errorf("invalid color %q", text)
+	}
+	*h = FromHEX(string(text))
+	return nil
 }
@@ -1,6 +1,9 @@
 package termcolor
 
-import "testing"
+import (
+	"math"
+	"testing"
+)
 
 func Test_HEX(t *testing.T) {
 	for _, tt := range []struct {
@@ -24,3 +27,56 @@ func Test_HEX(t *testing.T) {
 		})
 	}
 }
+
+func Test_gamutMap(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		l, a, b float64
+	}{
+		{name: "in gamut", l: 0.5, a: 0.1, b: 0.1},
+		{name: "saturated red", l: 0.4, a: 0.9, b: 0.6},
+		{name: "saturated blue", l: 0.3, a: 0.5, b: -1.2},
+		{name: "light green", l: 0.9, a: -0.8, b: 0.5},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			src := color(tt.l, tt.a, tt.b)
+			got := src.inGamut()
+			if !inGamut(got.src) {
+				t.Fatalf("inGamut() = %v, out of sRGB gamut", got.src)
+			}
+			if inGamut(src.src) != (got.moved == 0) {
+				t.Errorf("inGamut().moved = %0.3f", got.moved)
+			}
+			sh, _, sl := src.src.Hcl()
+			gh, _, gl := got.src.Hcl()
+			if math.Abs(sl-gl) > 1e-3 {
+				t.Errorf("lightness changed: %0.4f -> %0.4f", sl, gl)
+			}
+			if math.Abs(sh-gh) > 0.5 {
+				t.Errorf("hue changed: %0.2f -> %0.2f", sh, gh)
+			}
+		})
+	}
+}
+
+func TestColor_Simulate(t *testing.T) {
+	for _, d := range []Deficiency{Protanopia, Deuteranopia, Tritanopia, Achromatopsia} {
+		t.Run(d.String(), func(t *testing.T) {
+			for _, hex := range []string{"#000000", "#ffffff"} {
+				if got := FromHEX(hex).Simulate(d).HEX(); got != hex {
+					t.Errorf("Simulate(%s) = %s", hex, got)
+				}
+			}
+			red, green := FromHEX("#5f0000").Simulate(d), FromHEX("#005f00").Simulate(d)
+			if d == Achromatopsia && chroma(red) > 1e-3 {
+				t.Errorf("Simulate(red) = %s, want gray", red.HEX())
+			}
+			if d == Protanopia || d == Deuteranopia {
+				orig := FromHEX("#5f0000").src.DistanceCIE76(FromHEX("#005f00").src)
+				if sim := red.src.DistanceCIE76(green.src); sim >= orig/2 {
+					t.Errorf("red/green distance = %0.3f, original %0.3f", sim, orig)
+				}
+			}
+		})
+	}
+}
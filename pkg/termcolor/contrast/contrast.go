@@ -0,0 +1,128 @@
+// Package contrast computes readability metrics of color pairs of a generated table.
+package contrast
+
+import (
+	"math"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+// WCAG returns WCAG 2.x contrast ratio of the colors in [1..21] range.
+func WCAG(fg, bg termcolor.Color) float64 {
+	a, b := fg.Luminance(), bg.Luminance()
+	if a < b {
+		a, b = b, a
+	}
+	return (a + 0.05) / (b + 0.05)
+}
+
+// APCA returns lightness contrast (Lc) of text [fg] on background [bg] by
+// APCA 0.0.98G-4g constants. Result is positive for dark text on light
+// background and negative for light text on dark background.
+func APCA(fg, bg termcolor.Color) float64 {
+	const (
+		blkThrs  = 0.022
+		blkClmp  = 1.414
+		deltaMin = 0.0005
+		scale    = 1.14
+		offset   = 0.027
+		loClip   = 0.1
+	)
+	ty, by := apcaLuminance(fg), apcaLuminance(bg)
+	if ty < blkThrs {
+		ty += math.Pow(blkThrs-ty, blkClmp)
+	}
+	if by < blkThrs {
+		by += math.Pow(blkThrs-by, blkClmp)
+	}
+	if math.Abs(by-ty) < deltaMin {
+		return 0
+	}
+	var lc float64
+	if by > ty {
+		if sapc := (math.Pow(by, 0.56) - math.Pow(ty, 0.57)) * scale; sapc >= loClip {
+			lc = sapc - offset
+		}
+	} else {
+		if sapc := (math.Pow(by, 0.65) - math.Pow(ty, 0.62)) * scale; sapc <= -loClip {
+			lc = sapc + offset
+		}
+	}
+	return lc * 100
+}
+
+func apcaLuminance(c termcolor.Color) float64 {
+	r, g, b := c.RGB()
+	lin := func(v uint8) float64 {
+		return math.Pow(float64(v)/255, 2.4)
+	}
+	return 0.2126729*lin(r) + 0.7151522*lin(g) + 0.0721750*lin(b)
+}
+
+// Special indexes of the pair colors.
+const (
+	Background = -1
+	Foreground = -2
+)
+
+// Pair of foreground and background color indexes (0-255, Background or Foreground).
+type Pair struct {
+	Fg, Bg int
+
+	// Informational pairs are reported, but not checked against thresholds.
+	Info bool
+}
+
+// CommonPairs returns pairs of usual text usages:
+// 0-15 on the background, foreground on 52/88/22/28 diff backgrounds
+// and grayscale on the background (informational).
+func CommonPairs() []Pair {
+	pairs := make([]Pair, 0, 16+4+24)
+	for i := range 16 {
+		// Black is used as a background shade rather than a text color.
+		pairs = append(pairs, Pair{Fg: i, Bg: Background, Info: i == 0})
+	}
+	for _, i := range []int{52, 88, 22, 28} {
+		pairs = append(pairs, Pair{Fg: Foreground, Bg: i})
+	}
+	for i := 232; i < 256; i++ {
+		pairs = append(pairs, Pair{Fg: i, Bg: Background, Info: true})
+	}
+	return pairs
+}
+
+// Color returns color of the table by index (0-255, Background or Foreground).
+// Missing foreground is replaced with color 7.
+func Color(cs termcolor.Table, index int) termcolor.Color {
+	switch index {
+	case Background:
+		return cs.Background()
+	case Foreground:
+		if fg := cs.Foreground(); !fg.Nil() {
+			return fg
+		}
+		return cs.Color(7)
+	}
+	return cs.Color(index)
+}
+
+// Result of the pair check.
+type Result struct {
+	Pair
+	WCAG float64
+	APCA float64
+	Pass bool
+}
+
+// Check computes WCAG and APCA contrast of the pairs.
+// Pair passes if its WCAG ratio is at least minWCAG and absolute APCA Lc at least minAPCA.
+func Check(cs termcolor.Table, pairs []Pair, minWCAG, minAPCA float64) []Result {
+	results := make([]Result, len(pairs))
+	for i, p := range pairs {
+		fg, bg := Color(cs, p.Fg), Color(cs, p.Bg)
+		r := Result{Pair: p, WCAG: WCAG(fg, bg), APCA: APCA(fg, bg)}
+		r.Pass = p.Info || r.WCAG >= minWCAG && math.Abs(r.APCA) >= minAPCA
+		results[i] = r
+	}
+	return results
+}
@@ -0,0 +1,113 @@
+package contrast
+
+import (
+	"math"
+	"testing"
+
+	"github.com/shagohead/cterm256/pkg/termcolor"
+)
+
+func TestWCAG(t *testing.T) {
+	for _, tt := range []struct {
+		fg, bg string
+		want   float64
+	}{
+		{fg: "#000000", bg: "#ffffff", want: 21},
+		{fg: "#ffffff", bg: "#000000", want: 21},
+		{fg: "#777777", bg: "#ffffff", want: 4.48},
+		{fg: "#ffffff", bg: "#ffffff", want: 1},
+	} {
+		t.Run(tt.fg+" on "+tt.bg, func(t *testing.T) {
+			if got := WCAG(termcolor.FromHEX(tt.fg), termcolor.FromHEX(tt.bg)); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("WCAG() = %0.3f, want %0.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAPCA(t *testing.T) {
+	for _, tt := range []struct {
+		fg, bg string
+		want   float64
+	}{
+		{fg: "#000000", bg: "#ffffff", want: 106.04},
+		{fg: "#ffffff", bg: "#000000", want: -107.88},
+		{fg: "#888888", bg: "#ffffff", want: 63.06},
+		{fg: "#ffffff", bg: "#888888", want: -68.54},
+		{fg: "#ffffff", bg: "#ffffff", want: 0},
+	} {
+		t.Run(tt.fg+" on "+tt.bg, func(t *testing.T) {
+			if got := APCA(termcolor.FromHEX(tt.fg), termcolor.FromHEX(tt.bg)); math.Abs(got-tt.want) > 0.01 {
+				t.Errorf("APCA() = %0.3f, want %0.2f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	cs := new(termcolor.Palette)
+	cs.SetBackground(termcolor.FromHEX("#ffffff"))
+	for n, hex := range map[int]string{
+		1:  "#777777",
+		2:  "#888888",
+		3:  "#ffffff",
+		7:  "#000000", // Used as missing foreground.
+		52: "#ffffff",
+	} {
+		cs.SetColor(n, termcolor.FromHEX(hex))
+	}
+	for _, tt := range []struct {
+		name             string
+		pair             Pair
+		minWCAG, minAPCA float64
+		want             bool
+	}{
+		{name: "below WCAG", pair: Pair{Fg: 1, Bg: Background}, minWCAG: 4.5, want: false},
+		{name: "above WCAG", pair: Pair{Fg: 1, Bg: Background}, minWCAG: 4.4, want: true},
+		{name: "at WCAG", pair: Pair{Fg: 1, Bg: Background}, minWCAG: WCAG(termcolor.FromHEX("#777777"), termcolor.FromHEX("#ffffff")), want: true},
+		{name: "missing foreground", pair: Pair{Fg: Foreground, Bg: 52}, minWCAG: 20.9, minAPCA: 100, want: true},
+		{name: "above APCA", pair: Pair{Fg: 2, Bg: Background}, minWCAG: 3, minAPCA: 60, want: true},
+		{name: "below APCA", pair: Pair{Fg: 2, Bg: Background}, minWCAG: 3, minAPCA: 65, want: false},
+		{name: "negative APCA", pair: Pair{Fg: 3, Bg: 2}, minWCAG: 3, minAPCA: 65, want: true},
+		{name: "info", pair: Pair{Fg: 1, Bg: Background, Info: true}, minWCAG: 21, minAPCA: 100, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			results := Check(cs, []Pair{tt.pair}, tt.minWCAG, tt.minAPCA)
+			if len(results) != 1 {
+				t.Fatalf("Check() returns %d results, want 1", len(results))
+			}
+			r := results[0]
+			if r.Pair != tt.pair {
+				t.Errorf("Pair = %+v, want %+v", r.Pair, tt.pair)
+			}
+			fg, bg := Color(cs, tt.pair.Fg), Color(cs, tt.pair.Bg)
+			if r.WCAG != WCAG(fg, bg) || r.APCA != APCA(fg, bg) {
+				t.Errorf("WCAG, APCA = %0.2f, %0.2f, want %0.2f, %0.2f", r.WCAG, r.APCA, WCAG(fg, bg), APCA(fg, bg))
+			}
+			if r.Pass != tt.want {
+				t.Errorf("Pass = %t (WCAG %0.2f, APCA %0.2f), want %t", r.Pass, r.WCAG, r.APCA, tt.want)
+			}
+		})
+	}
+}
+
+func TestCommonPairs(t *testing.T) {
+	pairs := CommonPairs()
+	if len(pairs) != 16+4+24 {
+		t.Fatalf("CommonPairs() returns %d pairs, want %d", len(pairs), 16+4+24)
+	}
+	for i, p := range pairs {
+		var want Pair
+		switch {
+		case i < 16:
+			want = Pair{Fg: i, Bg: Background, Info: i == 0}
+		case i < 20:
+			want = Pair{Fg: Foreground, Bg: []int{52, 88, 22, 28}[i-16]}
+		default:
+			want = Pair{Fg: 232 + i - 20, Bg: Background, Info: true}
+		}
+		if p != want {
+			t.Errorf("pair %d = %+v, want %+v", i, p, want)
+		}
+	}
+}
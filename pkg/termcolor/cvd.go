@@ -0,0 +1,109 @@
+package termcolor
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Deficiency of color vision.
+type Deficiency int
+
+const (
+	NoDeficiency Deficiency = iota
+	Protanopia
+	Deuteranopia
+	Tritanopia
+	Achromatopsia
+)
+
+var deficiencyNames = [...]string{
+	NoDeficiency:  "none",
+	Protanopia:    "protanopia",
+	Deuteranopia:  "deuteranopia",
+	Tritanopia:    "tritanopia",
+	Achromatopsia: "achromatopsia",
+}
+
+// Simulation matrices for linear RGB with severity 1.0 by Machado, Oliveira and Fernandes (2009).
+var deficiencyMatrices = [...][3][3]float64{
+	Protanopia: {
+		{0.152286, 1.052583, -0.204868},
+		{0.114503, 0.786281, 0.099216},
+		{-0.003882, -0.048116, 1.051998},
+	},
+	Deuteranopia: {
+		{0.367322, 0.860646, -0.227968},
+		{0.280085, 0.672501, 0.047413},
+		{-0.011820, 0.042940, 0.968881},
+	},
+	Tritanopia: {
+		{1.255528, -0.076749, -0.178779},
+		{-0.078411, 0.930809, 0.147602},
+		{0.004733, 0.691367, 0.303900},
+	},
+}
+
+// String implements flag.Value.
+func (d Deficiency) String() string {
+	if d < 0 || int(d) >= len(deficiencyNames) {
+		return ""
+	}
+	return deficiencyNames[d]
+}
+
+// Set implements flag.Value.
+func (d *Deficiency) Set(val string) error {
+	for i, name := range deficiencyNames {
+		if name == val {
+			*d = Deficiency(i)
+			return nil
+		}
+	}
+	return errors.New("unknown color vision deficiency. supported values: protanopia deuteranopia tritanopia achromatopsia")
+}
+
+var _ flag.Value = (*Deficiency)(nil)
+
+// Simulate returns color as seen with color vision deficiency.
+func (h Color) Simulate(d Deficiency) Color {
+	if h.Nil() || d == NoDeficiency {
+		return h
+	}
+	var r, g, b float64
+	if d == Achromatopsia {
+		y := h.Luminance()
+		r, g, b = y, y, y
+	} else {
+		r, g, b = h.src.Clamped().LinearRgb()
+		rgb := mul3(deficiencyMatrices[d], r, g, b)
+		r, g, b = rgb[0], rgb[1], rgb[2]
+	}
+	return Color{src: colorful.LinearRgb(r, g, b).Clamped(), set: true}
+}
+
+// Simulate returns view of the table with all colors as seen with color vision deficiency.
+func Simulate(cs Table, d Deficiency) Table {
+	return simulated{cs, d}
+}
+
+type simulated struct {
+	Table
+	deficiency Deficiency
+}
+
+// Color implements Table.
+func (s simulated) Color(number int) Color {
+	return s.Table.Color(number).Simulate(s.deficiency)
+}
+
+// Background implements Table.
+func (s simulated) Background() Color {
+	return s.Table.Background().Simulate(s.deficiency)
+}
+
+// Foreground implements Table.
+func (s simulated) Foreground() Color {
+	return s.Table.Foreground().Simulate(s.deficiency)
+}
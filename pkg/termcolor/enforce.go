@@ -0,0 +1,69 @@
+package termcolor
+
+import (
+	"fmt"
+	"io"
+	"math"
+)
+
+// contrastStep is the lightness step of contrast enforcement.
+const contrastStep = 0.005
+
+// contrastEnforcer changes lightness of colors to reach minimal contrast.
+type contrastEnforcer struct {
+	space  ColorSpace
+	metric func(fg, bg Color) float64
+	min    float64
+	warns  io.Writer
+}
+
+func (o Options) contrast(space ColorSpace) contrastEnforcer {
+	return contrastEnforcer{space: space, metric: o.Contrast, min: o.MinContrast, warns: o.Warns}
+}
+
+// text makes [number] color readable on [bg].
+func (e contrastEnforcer) text(cs Table, number int, bg Color) {
+	e.enforce(cs, number, bg, func(c Color) float64 {
+		return e.metric(c, bg)
+	})
+}
+
+// background makes [fg] readable on [number] color.
+func (e contrastEnforcer) background(cs Table, number int, fg Color) {
+	e.enforce(cs, number, fg, func(c Color) float64 {
+		return e.metric(fg, c)
+	})
+}
+
+// enforce moves lightness of [number] color away from [other] until contrast reaches minimum.
+func (e contrastEnforcer) enforce(cs Table, number int, other Color, contrast func(c Color) float64) {
+	src := cs.Color(number)
+	if e.min <= 0 || src.Nil() || math.Abs(contrast(src)) >= e.min {
+		return
+	}
+	sl, x, y := e.space.To(src)
+	ol, _, _ := e.space.To(other)
+	dir := 1.0
+	if sl < ol || sl == ol && ol > 0.5 {
+		dir = -1
+	}
+	c, l := src, sl
+	for {
+		next := l + dir*contrastStep
+		if next < 0 || next > 1 {
+			break
+		}
+		l = next
+		c = e.space.From(l, x, y).inGamut()
+		if math.Abs(contrast(c)) >= e.min {
+			break
+		}
+	}
+	cs.SetColor(number, c)
+	status := ""
+	if math.Abs(contrast(c)) < e.min {
+		status = fmt.Sprintf(" (minimum %0.2f is unreachable)", e.min)
+	}
+	fmt.Fprintf(e.warns, "contrast: %d lightness %+0.3f, contrast %0.2f -> %0.2f%s\n",
+		number, l-sl, math.Abs(contrast(src)), math.Abs(contrast(c)), status)
+}
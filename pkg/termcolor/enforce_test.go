@@ -0,0 +1,31 @@
+package termcolor
+
+import (
+	"io"
+	"testing"
+)
+
+func TestEnforceSkipsBlack(t *testing.T) {
+	wcag := func(fg, bg Color) float64 {
+		a, b := fg.Luminance(), bg.Luminance()
+		return (max(a, b) + 0.05) / (min(a, b) + 0.05)
+	}
+	// Black of the dark theme is close to background.
+	tbl := newTable("#1d1f21", "#c5c8c6",
+		"#282a2e", "#a54242", "#8c9440", "#de935f",
+		"#5f819d", "#85678f", "#5e8d87", "#707880",
+	)
+	opts := DefaultOptions()
+	opts.MinContrast = 4.5
+	opts.Contrast = wcag
+	opts.Warns = io.Discard
+	if err := GenerateWithOptions(tbl, opts); err != nil {
+		t.Fatal("GenerateWithOptions():", err)
+	}
+	if got := tbl.Color(0).HEX(); got != "#282a2e" {
+		t.Errorf("Color(0) = %s, want unchanged #282a2e", got)
+	}
+	if got := wcag(tbl.Color(1), tbl.Background()); got < opts.MinContrast {
+		t.Errorf("contrast of 1 on background = %0.2f", got)
+	}
+}
@@ -0,0 +1,57 @@
+package termcolor
+
+import "github.com/lucasb-eyer/go-colorful"
+
+// gamutEpsilon is tolerance of sRGB channel values for float rounding errors.
+const gamutEpsilon = 1e-6
+
+func inGamut(c colorful.Color) bool {
+	for _, v := range [3]float64{c.R, c.G, c.B} {
+		if v < -gamutEpsilon || v > 1+gamutEpsilon {
+			return false
+		}
+	}
+	return true
+}
+
+// gamutMap brings color into sRGB gamut by reducing its chroma
+// with constant lightness and hue in CIE LCh(ab).
+// Colors with lightness out of range can't be fixed this way and left for clamping.
+func gamutMap(c colorful.Color) colorful.Color {
+	if inGamut(c) {
+		return c
+	}
+	h, chroma, l := c.Hcl()
+	if !inGamut(colorful.Hcl(h, 0, l)) {
+		return c.Clamped()
+	}
+	lo, hi := 0.0, chroma
+	for hi-lo > 1e-5 {
+		mid := (lo + hi) / 2
+		if inGamut(colorful.Hcl(h, mid, l)) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return colorful.Hcl(h, lo, l).Clamped()
+}
+
+// inGamut returns color mapped into sRGB gamut, with distance of that move.
+func (h Color) inGamut() Color {
+	mapped := gamutMap(h.src)
+	if mapped == h.src {
+		return h
+	}
+	return Color{src: mapped, set: h.set, moved: h.moved + h.src.DistanceCIE76(mapped)}
+}
+
+// gamutTable maps every color set during generation into sRGB gamut.
+type gamutTable struct {
+	Table
+}
+
+// SetColor implements Table.
+func (t gamutTable) SetColor(number int, color Color) {
+	t.Table.SetColor(number, color.inGamut())
+}
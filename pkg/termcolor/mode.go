@@ -0,0 +1,95 @@
+package termcolor
+
+import (
+	"flag"
+	"fmt"
+	"math"
+)
+
+// Mode of the color scheme: dark or light.
+type Mode int
+
+const (
+	// Detect mode by scheme colors.
+	ModeAuto Mode = iota
+	ModeDark
+	ModeLight
+)
+
+var modeNames = [...]string{
+	ModeAuto:  "auto",
+	ModeDark:  "dark",
+	ModeLight: "light",
+}
+
+// String implements fmt.Stringer.
+func (m Mode) String() string {
+	if m < 0 || int(m) >= len(modeNames) {
+		return fmt.Sprintf("Mode(%d)", m)
+	}
+	return modeNames[m]
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (m Mode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (m *Mode) UnmarshalText(text []byte) error {
+	for i, name := range modeNames {
+		if name == string(text) {
+			*m = Mode(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown mode %q: supported values: auto dark light", text)
+}
+
+// Set implements flag.Value.
+func (m *Mode) Set(val string) error {
+	return m.UnmarshalText([]byte(val))
+}
+
+var _ flag.Value = (*Mode)(nil)
+
+// minModeContrast is the minimal difference of relative luminance between
+// background and foreground, which is enough to tell dark scheme from light one.
+const minModeContrast = 0.05
+
+// DetectMode tells whether scheme is dark or light.
+// Background luminance is compared with foreground one (or with average of colors 1-7 if foreground is missing).
+// If they are too close, background lightness is used alone.
+func DetectMode(cs Table) Mode {
+	bg := cs.Background()
+	if bg.Nil() {
+		bg = cs.Color(0)
+	}
+	if bg.Nil() {
+		return ModeDark
+	}
+	fg, ok := cs.Foreground().Luminance(), !cs.Foreground().Nil()
+	if !ok {
+		var n int
+		fg = 0
+		for i := 1; i < 8; i++ {
+			if c := cs.Color(i); !c.Nil() {
+				fg += c.Luminance()
+				n++
+			}
+		}
+		if ok = n > 0; ok {
+			fg /= float64(n)
+		}
+	}
+	if bl := bg.Luminance(); ok && math.Abs(fg-bl) >= minModeContrast {
+		if fg > bl {
+			return ModeDark
+		}
+		return ModeLight
+	}
+	if bg.Lightness() < 0.5 {
+		return ModeDark
+	}
+	return ModeLight
+}
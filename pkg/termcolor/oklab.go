@@ -0,0 +1,69 @@
+package termcolor
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Oklab conversions by Björn Ottosson: https://bottosson.github.io/posts/oklab/
+
+func toOklab(c colorful.Color) (l, a, b float64) {
+	r, g, bl := c.LinearRgb()
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+	l = 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc
+	a = 1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc
+	b = 0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+	return
+}
+
+func fromOklab(l, a, b float64) colorful.Color {
+	lc := l + 0.3963377774*a + 0.2158037573*b
+	mc := l - 0.1055613458*a - 0.0638541728*b
+	sc := l - 0.0894841775*a - 1.2914855480*b
+	lc, mc, sc = lc*lc*lc, mc*mc*mc, sc*sc*sc
+	return colorful.LinearRgb(
+		+4.0767416621*lc-3.3077115913*mc+0.2309699292*sc,
+		-1.2684380046*lc+2.6097574011*mc-0.3413193965*sc,
+		-0.0041960863*lc-0.7034186147*mc+1.7076147010*sc,
+	)
+}
+
+type oklabSpace struct{}
+
+// To implements ColorSpace.
+func (oklabSpace) To(c Color) (l, x, y float64) {
+	return toOklab(c.src)
+}
+
+// From implements ColorSpace.
+func (oklabSpace) From(l, x, y float64) Color {
+	return Color{src: fromOklab(l, x, y), set: true}
+}
+
+// Blend implements ColorSpace.
+func (oklabSpace) Blend(x1, y1, x2, y2, scale float64) (x, y float64) {
+	return blend(x1, x2, scale), blend(y1, y2, scale)
+}
+
+type oklchSpace struct{}
+
+// To implements ColorSpace. Chromatic components are hue and chroma.
+func (oklchSpace) To(c Color) (l, x, y float64) {
+	l, a, b := toOklab(c.src)
+	h := math.Mod(math.Atan2(b, a)*180/math.Pi+360, 360)
+	return l, h, math.Hypot(a, b)
+}
+
+// From implements ColorSpace.
+func (oklchSpace) From(l, x, y float64) Color {
+	h := x * math.Pi / 180
+	return Color{src: fromOklab(l, y*math.Cos(h), y*math.Sin(h)), set: true}
+}
+
+// Blend implements ColorSpace.
+func (oklchSpace) Blend(x1, y1, x2, y2, scale float64) (x, y float64) {
+	return blendHue(x1, y1, x2, y2, scale), blend(y1, y2, scale)
+}
@@ -0,0 +1,80 @@
+package termcolor
+
+import "fmt"
+
+// Palette is the in-memory Table, which isn't bound to any file format.
+// It is used to convert color scheme from one format to another.
+type Palette struct {
+	indexed    [256]Color
+	background Color
+	foreground Color
+}
+
+// NewPalette returns palette with colors of the table.
+func NewPalette(cs Table) *Palette {
+	p := &Palette{background: cs.Background(), foreground: cs.Foreground()}
+	for n := range p.indexed {
+		p.indexed[n] = cs.Color(n)
+	}
+	return p
+}
+
+// Color implements Table.
+func (p *Palette) Color(number int) Color {
+	if number > 255 || number < 0 {
+		panic("color number out of bounds")
+	}
+	return p.indexed[number]
+}
+
+// SetColor implements Table.
+func (p *Palette) SetColor(number int, color Color) {
+	p.indexed[number] = color
+}
+
+// Background implements Table.
+func (p *Palette) Background() Color {
+	return p.background
+}
+
+// SetBackground sets background color.
+func (p *Palette) SetBackground(color Color) {
+	p.background = color
+}
+
+// Foreground implements Table.
+func (p *Palette) Foreground() Color {
+	return p.foreground
+}
+
+// SetForeground sets foreground color.
+func (p *Palette) SetForeground(color Color) {
+	p.foreground = color
+}
+
+// Write implements Table.
+// Palette is written as «name #rrggbb» lines of defined colors.
+func (p *Palette) Write(w Writer) error {
+	for _, c := range []struct {
+		name  string
+		color Color
+	}{{"background", p.background}, {"foreground", p.foreground}} {
+		if c.color.Nil() {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %s\n", c.name, c.color.HEX()); err != nil {
+			return err
+		}
+	}
+	for n, c := range p.indexed {
+		if c.Nil() {
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "color%d %s\n", n, c.HEX()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var _ Table = (*Palette)(nil)
@@ -0,0 +1,46 @@
+package termcolor
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type profile struct {
+	Space string `json:"space" toml:"space"`
+	*Options
+}
+
+// LoadOptions reads generation options profile over opts.
+// Profile format (JSON or TOML) is determined by file extension.
+// Options missing in profile are kept as is.
+func LoadOptions(name string, opts *Options) error {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	p := profile{Options: opts}
+	if strings.EqualFold(filepath.Ext(name), ".json") {
+		err = json.Unmarshal(data, &p)
+	} else {
+		err = toml.Unmarshal(data, &p)
+	}
+	if err != nil {
+		return fmt.Errorf("profile %s: %v", name, err)
+	}
+	if p.Space != "" {
+		space, ok := spaces[strings.ToLower(p.Space)]
+		if !ok {
+			return fmt.Errorf("profile %s: unknown color space %q", name, p.Space)
+		}
+		opts.Space = space
+	}
+	if err := opts.Grayscale.validate(); err != nil {
+		return fmt.Errorf("profile %s: %v", name, err)
+	}
+	return nil
+}
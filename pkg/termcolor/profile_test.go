@@ -0,0 +1,55 @@
+package termcolor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadOptions(t *testing.T) {
+	for _, name := range []string{"testdata/profile.toml", "testdata/profile.json"} {
+		t.Run(name, func(t *testing.T) {
+			opts := DefaultOptions()
+			if err := LoadOptions(name, &opts); err != nil {
+				t.Fatal("LoadOptions():", err)
+			}
+			if opts.Space != OKLCh {
+				t.Errorf("Space = %T, want OKLCh", opts.Space)
+			}
+			if opts.Mode != ModeLight {
+				t.Errorf("Mode = %s, want light", opts.Mode)
+			}
+			if want := [5]float64{0, 0.25, 0.5, 0.75, 1}; opts.Gradient != want {
+				t.Errorf("Gradient = %v, want %v", opts.Gradient, want)
+			}
+			if want := (CubeCurve{Side: 0.2, Shift: 0.05, Mix: 0.5, MixStep: 0.1}); opts.Cube != want {
+				t.Errorf("Cube = %+v, want %+v", opts.Cube, want)
+			}
+			if g := opts.Grayscale; g.From != 0 || g.To != 1 || !g.Start.Nil() || g.End.HEX() != "#ffffff" {
+				t.Errorf("Grayscale = %+v", g)
+			}
+		})
+	}
+}
+
+func TestLoadOptionsGrayscaleRange(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		profile string
+	}{
+		{name: "empty range", profile: "[grayscale]\nfrom = 0.5\nto = 0.5\n"},
+		{name: "reversed range", profile: "[grayscale]\nfrom = 0.9\nto = 0.1\n"},
+		{name: "out of bounds", profile: "[grayscale]\nfrom = -0.1\nto = 1.2\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			name := filepath.Join(t.TempDir(), "profile.toml")
+			if err := os.WriteFile(name, []byte(tt.profile), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			opts := DefaultOptions()
+			if err := LoadOptions(name, &opts); err == nil {
+				t.Errorf("LoadOptions() succeeded with grayscale %+v", opts.Grayscale)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package termcolor
+
+import (
+	"errors"
+	"flag"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// ColorSpace in which palette generation is made.
+// Coordinates are split into lightness (in [0..1] range) and two chromatic components.
+type ColorSpace interface {
+	// To converts color into space coordinates.
+	To(c Color) (l, x, y float64)
+
+	// From converts space coordinates into color.
+	From(l, x, y float64) Color
+
+	// Blend interpolates chromatic components with [scale] from (x1, y1) to (x2, y2).
+	Blend(x1, y1, x2, y2, scale float64) (x, y float64)
+}
+
+var (
+	// CIE Lab color space.
+	Lab ColorSpace = labSpace{}
+
+	// HSLuv color space: CIELUV based HSL with hue and saturation as chromatic components.
+	HSLuv ColorSpace = hsluvSpace{}
+
+	// Oklab color space.
+	OKLab ColorSpace = oklabSpace{}
+
+	// Oklab in polar coordinates, hue is interpolated by shortest arc.
+	OKLCh ColorSpace = oklchSpace{}
+
+	// CAM16 uniform color space with sRGB viewing conditions.
+	CAM16UCS ColorSpace = cam16Space{}
+)
+
+var spaces = map[string]ColorSpace{
+	"lab":      Lab,
+	"hsluv":    HSLuv,
+	"oklab":    OKLab,
+	"oklch":    OKLCh,
+	"cam16ucs": CAM16UCS,
+}
+
+// SpaceNames returns sorted names of supported color spaces.
+func SpaceNames() []string {
+	names := make([]string, 0, len(spaces))
+	for name := range spaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ColorSpace selector flag.
+type SpaceFlag struct {
+	Name  string
+	Space ColorSpace
+}
+
+// Set implements flag.Value.
+func (f *SpaceFlag) Set(val string) error {
+	space, ok := spaces[strings.ToLower(val)]
+	if !ok {
+		return errors.New("unknown color space. supported values: " + strings.Join(SpaceNames(), " "))
+	}
+	f.Name, f.Space = val, space
+	return nil
+}
+
+// String implements flag.Value.
+func (f *SpaceFlag) String() string {
+	return f.Name
+}
+
+var _ flag.Value = (*SpaceFlag)(nil)
+
+type labSpace struct{}
+
+// To implements ColorSpace.
+func (labSpace) To(c Color) (l, x, y float64) {
+	return c.src.Lab()
+}
+
+// From implements ColorSpace.
+func (labSpace) From(l, x, y float64) Color {
+	return color(l, x, y)
+}
+
+// Blend implements ColorSpace.
+func (labSpace) Blend(x1, y1, x2, y2, scale float64) (x, y float64) {
+	return blend(x1, x2, scale), blend(y1, y2, scale)
+}
+
+type hsluvSpace struct{}
+
+// To implements ColorSpace. Chromatic components are hue and saturation.
+func (hsluvSpace) To(c Color) (l, x, y float64) {
+	h, s, l := c.src.HSLuv()
+	return l, h, s
+}
+
+// From implements ColorSpace.
+func (hsluvSpace) From(l, x, y float64) Color {
+	return Color{src: colorful.HSLuv(x, y, l), set: true}
+}
+
+// Blend implements ColorSpace.
+func (hsluvSpace) Blend(x1, y1, x2, y2, scale float64) (x, y float64) {
+	return blendHue(x1, y1, x2, y2, scale), blend(y1, y2, scale)
+}
+
+// achromatic is the chroma (or saturation) below which hue of the color is meaningless.
+const achromatic = 1e-4
+
+// blendHue interpolates hue angles (degrees) by shortest arc.
+// Hue of achromatic color is replaced by the other one, so grays do not tint blends.
+func blendHue(h1, c1, h2, c2, scale float64) float64 {
+	switch {
+	case c1 < achromatic:
+		return h2
+	case c2 < achromatic:
+		return h1
+	}
+	d := math.Mod(h2-h1+540, 360) - 180
+	return math.Mod(h1+scale*d+360, 360)
+}
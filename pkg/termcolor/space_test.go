@@ -0,0 +1,36 @@
+package termcolor
+
+import "testing"
+
+func TestColorSpaceRoundTrip(t *testing.T) {
+	colors := []string{"#000000", "#ffffff", "#808080", "#e78284", "#a6d189", "#8caaee", "#303446", "#ffff00"}
+	for _, name := range SpaceNames() {
+		space := spaces[name]
+		t.Run(name, func(t *testing.T) {
+			for _, hex := range colors {
+				c := FromHEX(hex)
+				if got := space.From(space.To(c)); got.HEX() != c.HEX() {
+					t.Errorf("From(To(%s)) = %s", hex, got.HEX())
+				}
+				if l, _, _ := space.To(c); l < -1e-6 || l > 1+1e-6 {
+					t.Errorf("To(%s) lightness = %0.3f, out of [0..1]", hex, l)
+				}
+			}
+		})
+	}
+}
+
+func Test_blendHue(t *testing.T) {
+	for _, tt := range []struct {
+		h1, c1, h2, c2, scale, want float64
+	}{
+		{h1: 10, c1: 1, h2: 350, c2: 1, scale: 0.5, want: 0},
+		{h1: 350, c1: 1, h2: 30, c2: 1, scale: 0.25, want: 0},
+		{h1: 90, c1: 1, h2: 180, c2: 1, scale: 0.5, want: 135},
+		{h1: 90, c1: 0, h2: 180, c2: 1, scale: 0.5, want: 180},
+	} {
+		if got := blendHue(tt.h1, tt.c1, tt.h2, tt.c2, tt.scale); got != tt.want {
+			t.Errorf("blendHue(%v, %v, %v) = %v, want %v", tt.h1, tt.h2, tt.scale, got, tt.want)
+		}
+	}
+}
@@ -4,8 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"io"
-
-	"github.com/lucasb-eyer/go-colorful"
+	"math"
+	"slices"
+	"sort"
 )
 
 // Color scheme API.
@@ -31,10 +32,105 @@ type Writer interface {
 	io.StringWriter
 }
 
-var errMissingBackground = errors.New("provided scheme missing (primary) background color")
+var (
+	errMissingBackground     = errors.New("provided scheme missing (primary) background color")
+	errMissingContrastMetric = errors.New("minimal contrast requires contrast metric")
+)
+
+// Generation options.
+type Options struct {
+	// Color space, in which lightness variations and blends are made.
+	Space ColorSpace `json:"-" toml:"-"`
+
+	// Keep original layout of schemes like Solarized, which uses bright colors as grays.
+	KeepLayout bool `json:"keep_layout" toml:"keep_layout"`
+
+	// Dark/light mode override. Detected with [DetectMode] if auto.
+	Mode Mode `json:"mode" toml:"mode"`
+
+	// Blend factors of r/g/b gradients (52-196, 22-46, 17-21) from background to color.
+	Gradient [5]float64 `json:"gradient" toml:"gradient"`
+
+	// Blend factors of 6x6x6 cube.
+	Cube CubeCurve `json:"cube" toml:"cube"`
+
+	// Minimal contrast of text colors (1-15, 196, 46, 21) with background
+	// and of foreground with diff backgrounds (52, 88, 22, 28, 17, 18).
+	// Lightness of these colors is changed to reach it. Zero disables the check.
+	// Black (0) is not a text color: dark themes use it as a background shade.
+	MinContrast float64 `json:"min_contrast" toml:"min_contrast"`
+
+	// Contrast metric of MinContrast, which absolute value is used. Required if MinContrast is set.
+	Contrast func(fg, bg Color) float64 `json:"-" toml:"-"`
+
+	// Grayscale (232-255) ramp.
+	Grayscale GrayscaleCurve `json:"grayscale" toml:"grayscale"`
+
+	// Warnings output.
+	Warns io.Writer `json:"-" toml:"-"`
+}
+
+// Blend factors of 6x6x6 cube colors.
+type CubeCurve struct {
+	// Factor of green blending into top row and left column per green step.
+	Side float64 `json:"side" toml:"side"`
+
+	// Decrease of Side factor per blue column and red row.
+	Shift float64 `json:"shift" toml:"shift"`
+
+	// Base factor of red and blue mixes.
+	Mix float64 `json:"mix" toml:"mix"`
+
+	// Change of Mix factor per blue column (increase) and red row (decrease).
+	MixStep float64 `json:"mix_step" toml:"mix_step"`
+}
+
+// Grayscale ramp between start and end colors.
+type GrayscaleCurve struct {
+	// Positions of the first (232) and the last (255) colors between start and end.
+	From float64 `json:"from" toml:"from"`
+	To   float64 `json:"to" toml:"to"`
+
+	// Start and end colors. Background and foreground are used if not set.
+	Start Color `json:"start" toml:"start"`
+	End   Color `json:"end" toml:"end"`
+}
+
+func (g GrayscaleCurve) validate() error {
+	if g.From < 0 || g.To > 1 || g.From >= g.To {
+		return fmt.Errorf("grayscale range [%g, %g] must be increasing within [0, 1]", g.From, g.To)
+	}
+	return nil
+}
+
+// DefaultOptions returns options which are used by [Generate].
+func DefaultOptions() Options {
+	return Options{
+		Space:    HSLuv,
+		Gradient: [5]float64{0, 0.2, 0.4, 0.6, 0.8},
+		Cube: CubeCurve{
+			Side:    0.15,
+			Shift:   0.05,
+			Mix:     0.5,
+			MixStep: 0.1,
+		},
+		Grayscale: GrayscaleCurve{
+			From: 1.0 / 25,
+			To:   24.0 / 25,
+		},
+		Warns: io.Discard,
+	}
+}
 
 // Generate 256 color palette based on first 8/16 + background & foreground.
 func Generate(cs Table, warns io.Writer) error {
+	opts := DefaultOptions()
+	opts.Warns = warns
+	return GenerateWithOptions(cs, opts)
+}
+
+// GenerateWithOptions generates 256 color palette like [Generate] but with provided options.
+func GenerateWithOptions(cs Table, opts Options) error {
 	for i := range 8 {
 		if cs.Color(i).Nil() {
 			return fmt.Errorf("provided scheme missing color %d", i)
@@ -44,10 +140,22 @@ func Generate(cs Table, warns io.Writer) error {
 	if background.Nil() {
 		return errMissingBackground
 	}
+	if opts.MinContrast > 0 && opts.Contrast == nil {
+		return errMissingContrastMetric
+	}
+	if err := opts.Grayscale.validate(); err != nil {
+		return err
+	}
+	cs = gamutTable{cs}
+	space := opts.Space
 
 	// Is it dark or light theme?
-	bglight := background.Lightness()
-	isDark := cs.Color(1).Lightness() > bglight
+	bglight, _, _ := space.To(background)
+	mode := opts.Mode
+	if mode == ModeAuto {
+		mode = DetectMode(cs)
+	}
+	isDark := mode == ModeDark
 	contrast := maxContrast(isDark)
 
 	// Swap black and white colors for light theme if needed.
@@ -62,9 +170,22 @@ func Generate(cs Table, warns io.Writer) error {
 
 	// Set 16 from 0 with background lightness.
 	{
-		_, a, b := cs.Color(0).src.Lab()
-		l, _, _ := background.src.Lab()
-		cs.SetColor(16, Color{colorful.Lab(l, a, b), true})
+		_, x, y := space.To(cs.Color(0))
+		cs.SetColor(16, space.From(bglight, x, y))
+	}
+
+	// Schemes like Solarized uses bright colors as background/foreground grays.
+	// Move them to grayscale and derive these bright colors from normal ones.
+	// Chromatic bright colors (Solarized orange and violet) are kept.
+	var grays []Color
+	if !opts.KeepLayout && graybrights(cs) {
+		for i := 8; i < 16; i++ {
+			if c := cs.Color(i); i == 8 || i == 15 || chroma(c) < grayChroma {
+				grays = append(grays, c)
+				cs.SetColor(i, Color{})
+			}
+		}
+		fmt.Fprintln(opts.Warns, "bright colors are used as grays; will be moved to grayscale")
 	}
 
 	// Fix bright (and normal) colors: create, swap or change lightness if needed.
@@ -72,26 +193,42 @@ func Generate(cs Table, warns io.Writer) error {
 	for i := range 8 {
 		norm, bright := cs.Color(i), cs.Color(i+8)
 		if bright.Nil() {
-			l, a, b := norm.src.Lab()
-			cs.SetColor(i+8, color(tobright(l), a, b))
+			l, x, y := space.To(norm)
+			cs.SetColor(i+8, space.From(tobright(l), x, y))
 			continue
 		}
-		n, _, _ := norm.src.Lab()
-		b, _, _ := bright.src.Lab()
+		n, _, _ := space.To(norm)
+		b, _, _ := space.To(bright)
 		if n == b {
-			l, a, b := bright.src.Lab()
-			cs.SetColor(i+8, color(tobright(l), a, b))
+			l, x, y := space.To(bright)
+			cs.SetColor(i+8, space.From(tobright(l), x, y))
 			continue
 		}
-		// TODO: Fix schemes like Solarized, which uses bright as completly different colors.
-		// Solarized bright colors are background/foreground gradient.
-		// We already have 232-255 for this purpose.
 		if contrast(n, b) == n {
 			cs.SetColor(i, bright)
 			cs.SetColor(i+8, norm)
 		}
 	}
 
+	foreground := cs.Foreground()
+	if foreground.Nil() {
+		white := cs.Color(7)
+		foreground = white
+		repr := "white/7"
+		if brwhite := cs.Color(15); !brwhite.Nil() {
+			if contrast(brwhite.Lightness(), white.Lightness()) == brwhite.Lightness() {
+				foreground = brwhite
+				repr = "brwhite/15"
+			}
+		}
+		fmt.Fprintln(opts.Warns, "scheme missing foreground color; will use ", repr, " instead")
+	}
+
+	enforce := opts.contrast(space)
+	for i := 1; i < 16; i++ {
+		enforce.text(cs, i, background)
+	}
+
 	// R/g/b based gradients.
 	for _, c := range []struct {
 		brsource int    // Bright r/g/b
@@ -101,38 +238,124 @@ func Generate(cs Table, warns io.Writer) error {
 		{10, [5]int{22, 28, 34, 40, 46}},   // Green
 		{12, [5]int{17, 18, 19, 20, 21}},   // Blue
 	} {
-		gradient(cs, isDark, c.brsource, c.targets)
+		gradient(cs, space, isDark, opts.Gradient, c.brsource, c.targets)
+		enforce.text(cs, c.targets[4], background)
+		for _, n := range c.targets[:2] {
+			enforce.background(cs, n, foreground)
+		}
 	}
 
-	cube(cs, contrast)
+	cube(cs, space, opts.Cube, contrast)
 
-	foreground := cs.Foreground()
-	if foreground.Nil() {
-		white := cs.Color(7)
-		foreground = white
-		repr := "white/7"
-		if brwhite := cs.Color(15); !brwhite.Nil() {
-			if contrast(brwhite.Lightness(), white.Lightness()) == brwhite.Lightness() {
-				foreground = brwhite
-				repr = "brwhite/15"
-			}
-		}
-		fmt.Fprintln(warns, "scheme missing foreground color; will use ", repr, " instead")
+	end := foreground
+	if c := opts.Grayscale.End; !c.Nil() {
+		end = c
 	}
-	delta := 1.0 / 25
-	for i := range 24 {
-		s := delta * float64(i+1)
-		sl, sa, sb := background.src.Lab()
-		dl, da, db := foreground.src.Lab()
-		cs.SetColor(232+i, color(blend(sl, dl, s), blend(sa, da, s), blend(sb, db, s)))
+	if start := opts.Grayscale.Start; !start.Nil() {
+		background = start
 	}
+	grayscale(cs, space, opts.Grayscale, background, end, grays)
 	return nil
 }
 
+// grayChroma is the CIE LCh chroma below which color is treated as gray.
+const grayChroma = 0.15
+
+func chroma(c Color) float64 {
+	_, ch, _ := c.src.Hcl()
+	return ch
+}
+
+// graybrights reports whether most of bright colors (except black and white) are grays,
+// while their normal versions are not.
+func graybrights(cs Table) bool {
+	var n int
+	for i := 1; i < 7; i++ {
+		bright := cs.Color(i + 8)
+		if !bright.Nil() && chroma(bright) < grayChroma && chroma(cs.Color(i)) >= grayChroma {
+			n++
+		}
+	}
+	return n >= 3
+}
+
+// grayscale fills 232-255 with transition from background to foreground.
+// Provided grays are placed into the ramp by their lightness and used as intermediate stops.
+// If some of the grays has more contrast with background than foreground, ramp ends with it (at 255).
+func grayscale(cs Table, space ColorSpace, curve GrayscaleCurve, bg, fg Color, grays []Color) {
+	type stop struct {
+		pos   float64 // Index in ramp (0 is 232); background and end could be out of it.
+		color Color
+	}
+	end, to := fg, curve.To
+	bl := bg.Lightness()
+	for _, c := range grays {
+		if math.Abs(c.Lightness()-bl) > math.Abs(end.Lightness()-bl) {
+			end, to = c, 1
+		}
+	}
+	index := func(t float64) float64 {
+		return (t - curve.From) / (to - curve.From) * 23
+	}
+	stops := []stop{{index(0), bg}, {index(1), end}}
+	for _, c := range append(grays, fg) {
+		t := (c.Lightness() - bl) / (end.Lightness() - bl)
+		if math.IsNaN(t) {
+			continue
+		}
+		pos := math.Round(index(t))
+		if pos < 0 || pos > 23 || pos <= stops[0].pos || pos >= stops[len(stops)-1].pos {
+			continue
+		}
+		i := sort.Search(len(stops), func(i int) bool { return stops[i].pos >= pos })
+		if stops[i].pos == pos {
+			continue
+		}
+		stops = slices.Insert(stops, i, stop{pos, c})
+	}
+	for i := 1; i < len(stops); i++ {
+		a, b := stops[i-1], stops[i]
+		for pos := max(0, math.Floor(a.pos)+1); pos < b.pos && pos < 24; pos++ {
+			cs.SetColor(232+int(pos), mix(space, a.color, b.color, (pos-a.pos)/(b.pos-a.pos)))
+		}
+		if pos := b.pos; pos >= 0 && pos < 24 && pos == math.Trunc(pos) {
+			cs.SetColor(232+int(pos), b.color)
+		}
+	}
+	if first := stops[0].pos; first >= 0 && first == math.Trunc(first) {
+		cs.SetColor(232+int(first), stops[0].color)
+	}
+}
+
+// minBrightDistance is the minimal CIE76 distance between normal and bright variants of the color.
+const minBrightDistance = 0.1
+
+// brightStep is the part of available lightness range, which bright variant moves through.
+const brightStep = 0.25
+
+// makebright returns function which derives bright variant lightness from normal one.
+// Bright colors moves away from background: lighter for dark theme and darker for light.
+// If there is no room in that direction (e.g. white on dark theme), lightness moves backwards.
+// Result is at least minBrightDistance away from source and from background.
 func makebright(bg float64, dark bool) func(src float64) float64 {
+	dir, limit := 1.0, 1.0
+	if !dark {
+		dir, limit = -1, 0
+	}
 	return func(src float64) float64 {
-		// TODO: implement
-		return src
+		l := src + dir*math.Max(minBrightDistance, brightStep*math.Abs(limit-src))
+		if (l-limit)*dir > 0 {
+			l = limit
+			if math.Abs(l-src) < minBrightDistance {
+				l = src - dir*minBrightDistance
+			}
+		}
+		if math.Abs(l-bg) < minBrightDistance {
+			if l = bg + dir*minBrightDistance; (l-limit)*dir > 0 {
+				l = bg - dir*minBrightDistance
+			}
+		}
+		return l
 	}
 }
 
@@ -158,7 +381,7 @@ func maxContrast(isDark bool) func(a, b float64) float64 {
 	}
 }
 
-func gradient(cs Table, isDark bool, brindex int, targets [5]int) {
+func gradient(cs Table, space ColorSpace, isDark bool, curve [5]float64, brindex int, targets [5]int) {
 	bg := cs.Color(16)
 	src := cs.Color(brindex)
 	norm := cs.Color(brindex - 8)
@@ -172,35 +395,45 @@ func gradient(cs Table, isDark bool, brindex int, targets [5]int) {
 		}
 	}
 	for i, target := range targets {
-		d := float64(i) * 0.2
-		sl, _, _ := bg.src.Lab()
-		dl, da, db := src.src.Lab()
-		cs.SetColor(target, color(blend(sl, dl, d), da, db))
+		d := curve[i]
+		sl, _, _ := space.To(bg)
+		dl, dx, dy := space.To(src)
+		cs.SetColor(target, space.From(blend(sl, dl, d), dx, dy))
 	}
 }
 
+// mix blends colors a and b (lightness and chromatic coordinates) with [scale] in color space.
+func mix(space ColorSpace, a, b Color, scale float64) Color {
+	al, ax, ay := space.To(a)
+	bl, bx, by := space.To(b)
+	x, y := space.Blend(ax, ay, bx, by, scale)
+	return space.From(blend(al, bl, scale), x, y)
+}
+
 // Generate 6x6x6 colors cube
-func cube(cs Table, maxContrast func(a, b float64) float64) {
+func cube(cs Table, space ColorSpace, curve CubeCurve, maxContrast func(a, b float64) float64) {
 	for side := 1; side < 6; side++ {
 		green := cs.Color(side*6 + 16)
-		yl, ya, yb := green.src.Lab()
+		yl, yx, yy := space.To(green)
 
 		// Blue+green columns in top row.
 		for col := range 5 {
 			blue := cs.Color(col + 17)
 			target := side*6 + col + 17
-			xl, xa, xb := blue.src.Lab()
-			s := float64(side)*0.15 - float64(col)*0.05
-			cs.SetColor(target, color(maxContrast(xl, yl), blend(xa, ya, s), blend(xb, yb, s)))
+			xl, xx, xy := space.To(blue)
+			s := float64(side)*curve.Side - float64(col)*curve.Shift
+			x, y := space.Blend(xx, xy, yx, yy, s)
+			cs.SetColor(target, space.From(maxContrast(xl, yl), x, y))
 		}
 
 		// Red+green rows in left column.
 		for row := range 5 {
 			red := cs.Color(row*36 + 52)
 			target := side*6 + row*36 + 52
-			xl, xa, xb := red.src.Lab()
-			s := float64(side)*0.15 - float64(row)*0.05
-			cs.SetColor(target, color(maxContrast(xl, yl), blend(xa, ya, s), blend(xb, yb, s)))
+			xl, xx, xy := space.To(red)
+			s := float64(side)*curve.Side - float64(row)*curve.Shift
+			x, y := space.Blend(xx, xy, yx, yy, s)
+			cs.SetColor(target, space.From(maxContrast(xl, yl), x, y))
 		}
 	}
 
@@ -208,13 +441,11 @@ func cube(cs Table, maxContrast func(a, b float64) float64) {
 	for side := range 6 {
 		for col := range 5 {
 			blue := cs.Color(side*6 + col + 17)
-			yl, ya, yb := blue.src.Lab()
 			for row := range 5 {
 				red := cs.Color(side*6 + row*36 + 52)
 				target := side*6 + row*36 + col + 53
-				xl, xa, xb := red.src.Lab()
-				s := 0.5 + 0.1*float64(col) - 0.1*float64(row)
-				cs.SetColor(target, color(blend(xl, yl, s), blend(xa, ya, s), blend(xb, yb, s)))
+				s := curve.Mix + curve.MixStep*float64(col) - curve.MixStep*float64(row)
+				cs.SetColor(target, mix(space, red, blue, s))
 			}
 		}
 	}
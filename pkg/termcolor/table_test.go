@@ -0,0 +1,203 @@
+package termcolor
+
+import (
+	"io"
+	"slices"
+	"testing"
+)
+
+type table struct {
+	indexed    [256]Color
+	background Color
+	foreground Color
+}
+
+func newTable(bg, fg string, colors ...string) *table {
+	t := &table{background: FromHEX(bg), foreground: FromHEX(fg)}
+	for i, c := range colors {
+		t.indexed[i] = FromHEX(c)
+	}
+	return t
+}
+
+func (t *table) Color(number int) Color           { return t.indexed[number] }
+func (t *table) SetColor(number int, color Color) { t.indexed[number] = color }
+func (t *table) Background() Color                { return t.background }
+func (t *table) Foreground() Color                { return t.foreground }
+func (t *table) Write(w Writer) error             { return nil }
+
+func TestGenerateBright(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		table  *table
+		darker bool
+	}{
+		{
+			name: "dark 8 colors",
+			table: newTable("#1d1f21", "#c5c8c6",
+				"#282a2e", "#a54242", "#8c9440", "#de935f",
+				"#5f819d", "#85678f", "#5e8d87", "#707880",
+			),
+		},
+		{
+			name: "light 8 colors",
+			table: newTable("#fafafa", "#383a42",
+				"#fafafa", "#e45649", "#50a14f", "#c18401",
+				"#0184bc", "#a626a4", "#0997b3", "#383a42",
+			),
+			darker: true,
+		},
+		{
+			name: "dark with identical brights",
+			table: newTable("#000000", "#ffffff",
+				"#000000", "#cd0000", "#00cd00", "#cdcd00",
+				"#0000ee", "#cd00cd", "#00cdcd", "#ffffff",
+				"#000000", "#cd0000", "#00cd00", "#cdcd00",
+				"#0000ee", "#cd00cd", "#00cdcd", "#ffffff",
+			),
+		},
+	} {
+		for name, space := range spaces {
+			t.Run(tt.name+" in "+name, func(t *testing.T) {
+				tbl := new(table)
+				*tbl = *tt.table
+				opts := DefaultOptions()
+				opts.Space = space
+				if err := GenerateWithOptions(tbl, opts); err != nil {
+					t.Fatal("Generate():", err)
+				}
+				for i := range 8 {
+					norm, bright := tbl.Color(i), tbl.Color(i+8)
+					if d := norm.src.DistanceCIE76(bright.src); d < minBrightDistance-1e-9 {
+						t.Errorf("distance between %d and %d = %0.3f, want at least %0.3f", i, i+8, d, minBrightDistance)
+					}
+					if d := tbl.Background().src.DistanceCIE76(bright.src); d < minBrightDistance-1e-9 {
+						t.Errorf("distance between background and %d = %0.3f, want at least %0.3f", i+8, d, minBrightDistance)
+					}
+					if i == 0 || i == 7 {
+						// Black and white may be pushed backwards at the end of lightness range.
+						continue
+					}
+					if darker := bright.Lightness() < norm.Lightness(); darker != tt.darker {
+						t.Errorf("bright %d lightness %0.3f, normal %0.3f", i+8, bright.Lightness(), norm.Lightness())
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestGenerateGrayBrights(t *testing.T) {
+	solarized := newTable("#002b36", "#839496",
+		"#073642", "#dc322f", "#859900", "#b58900",
+		"#268bd2", "#d33682", "#2aa198", "#eee8d5",
+		"#002b36", "#cb4b16", "#586e75", "#657b83",
+		"#839496", "#6c71c4", "#93a1a1", "#fdf6e3",
+	)
+	grays := []string{"#586e75", "#657b83", "#93a1a1", "#fdf6e3"}
+
+	t.Run("remap", func(t *testing.T) {
+		tbl := new(table)
+		*tbl = *solarized
+		if err := Generate(tbl, io.Discard); err != nil {
+			t.Fatal("Generate():", err)
+		}
+		for i := 9; i < 15; i++ {
+			if c := chroma(tbl.Color(i)); c < grayChroma {
+				t.Errorf("bright %d chroma = %0.3f, want at least %0.3f", i, c, grayChroma)
+			}
+		}
+		for _, hex := range []string{"#cb4b16", "#6c71c4"} {
+			if !slices.ContainsFunc([]int{9, 13}, func(i int) bool { return tbl.Color(i).HEX() == hex }) {
+				t.Errorf("chromatic bright %s lost", hex)
+			}
+		}
+		ramp := make(map[string]bool)
+		for i := 232; i < 256; i++ {
+			ramp[tbl.Color(i).HEX()] = true
+		}
+		for _, hex := range grays {
+			if !ramp[hex] {
+				t.Errorf("gray %s missing in 232-255", hex)
+			}
+		}
+	})
+
+	t.Run("keep layout", func(t *testing.T) {
+		tbl := new(table)
+		*tbl = *solarized
+		opts := DefaultOptions()
+		opts.KeepLayout = true
+		if err := GenerateWithOptions(tbl, opts); err != nil {
+			t.Fatal("GenerateWithOptions():", err)
+		}
+		if got := tbl.Color(11).HEX(); got != "#657b83" && tbl.Color(3).HEX() != "#657b83" {
+			t.Errorf("bright yellow gray lost, got %s", got)
+		}
+	})
+}
+
+func TestDetectMode(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		table *table
+		want  Mode
+	}{
+		{name: "dark", table: newTable("#1d1f21", "#c5c8c6", "#282a2e", "#a54242"), want: ModeDark},
+		{name: "light", table: newTable("#fafafa", "#383a42", "#fafafa", "#e45649"), want: ModeLight},
+		{name: "dark red on light background", table: newTable("#f0f0f0", "#202020", "#000000", "#5f0000"), want: ModeLight},
+		{name: "mid-gray background", table: newTable("#777777", "#ffffff", "#000000", "#ff5555"), want: ModeDark},
+		{name: "low contrast", table: newTable("#8a8a8a", "#909090", "#000000", "#ff0000"), want: ModeLight},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DetectMode(tt.table); got != tt.want {
+				t.Errorf("DetectMode() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateMinContrast(t *testing.T) {
+	wcag := func(fg, bg Color) float64 {
+		a, b := fg.Luminance(), bg.Luminance()
+		return (max(a, b) + 0.05) / (min(a, b) + 0.05)
+	}
+	tbl := newTable("#303446", "#c6d0f5",
+		"#51576d", "#e78284", "#a6d189", "#e5c890",
+		"#8caaee", "#f4b8e4", "#81c8be", "#b5bfe2",
+		"#626880", "#e78284", "#a6d189", "#e5c890",
+		"#8caaee", "#f4b8e4", "#81c8be", "#a5adce",
+	)
+	opts := DefaultOptions()
+	opts.MinContrast = 4.5
+	opts.Contrast = wcag
+	if err := GenerateWithOptions(tbl, opts); err != nil {
+		t.Fatal("GenerateWithOptions():", err)
+	}
+	for _, i := range []int{1, 7, 8, 15, 196, 46, 21} {
+		if got := wcag(tbl.Color(i), tbl.Background()); got < opts.MinContrast {
+			t.Errorf("contrast of %d on background = %0.2f", i, got)
+		}
+	}
+	for _, i := range []int{52, 88, 22, 28, 17, 18} {
+		if got := wcag(tbl.Foreground(), tbl.Color(i)); got < opts.MinContrast {
+			t.Errorf("contrast of foreground on %d = %0.2f", i, got)
+		}
+	}
+
+	opts.Contrast = nil
+	if err := GenerateWithOptions(newTable("#000000", "#ffffff", "#000000", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff"), opts); err == nil {
+		t.Error("GenerateWithOptions() without contrast metric succeeded")
+	}
+}
+
+func TestGenerateGrayscaleRange(t *testing.T) {
+	for _, g := range []GrayscaleCurve{{From: 0.5, To: 0.5}, {From: 0.9, To: 0.1}, {From: -0.1, To: 1}, {From: 0, To: 1.1}} {
+		opts := DefaultOptions()
+		opts.Grayscale = g
+		tbl := newTable("#000000", "#ffffff", "#000000", "#ff0000", "#00ff00", "#ffff00", "#0000ff", "#ff00ff", "#00ffff", "#ffffff")
+		if err := GenerateWithOptions(tbl, opts); err == nil {
+			t.Errorf("GenerateWithOptions() succeeded with grayscale %+v", g)
+		}
+	}
+}